@@ -3,6 +3,7 @@ package alerts
 import (
 	"fmt"
 	"log"
+	"sync"
 	"time"
 
 	"github.com/abhijeet1999/weather/models"
@@ -10,6 +11,7 @@ import (
 
 // AlertEvaluator handles evaluating weather conditions and triggering alerts
 type AlertEvaluator struct {
+	mu         sync.RWMutex
 	alertRules map[string]AlertRule
 }
 
@@ -23,6 +25,8 @@ type AlertRule struct {
 	WindAlert     float32 `json:"wind_alert"`
 	HumidityAlert int     `json:"humidity_alert"`
 	PressureAlert int     `json:"pressure_alert"`
+	// CityConfigured is set when City comes from the locations file; otherwise it is learned from incoming messages
+	CityConfigured bool `json:"city_configured"`
 }
 
 // WeatherAlert represents a triggered alert
@@ -45,33 +49,108 @@ func NewAlertEvaluator() *AlertEvaluator {
 	}
 }
 
+// NewAlertRule creates an alert rule for a location from its configured thresholds
+func NewAlertRule(zipCode, city string, alertTemp, alertWind float32, alertHumidity int) AlertRule {
+	return AlertRule{
+		ZipCode:        zipCode,
+		City:           city,
+		AlertTemp:      alertTemp,
+		HighTempAlert:  alertTemp + 10, // 10°C above custom threshold
+		LowTempAlert:   alertTemp - 5,  // 5°C below custom threshold
+		WindAlert:      alertWind,      // User-specified wind threshold
+		HumidityAlert:  alertHumidity,  // User-specified humidity threshold
+		PressureAlert:  1000,           // 1000 hPa pressure (fixed)
+		CityConfigured: city != "",
+	}
+}
+
 // AddAlertRule adds an alert rule for a specific location
 func (ae *AlertEvaluator) AddAlertRule(zipCode, city string, alertTemp, alertWind float32, alertHumidity int) {
-	rule := AlertRule{
-		ZipCode:       zipCode,
-		City:          city,
-		AlertTemp:     alertTemp,
-		HighTempAlert: alertTemp + 10, // 10°C above custom threshold
-		LowTempAlert:  alertTemp - 5,  // 5°C below custom threshold
-		WindAlert:     alertWind,      // User-specified wind threshold
-		HumidityAlert: alertHumidity,  // User-specified humidity threshold
-		PressureAlert: 1000,           // 1000 hPa pressure (fixed)
-	}
+	rule := NewAlertRule(zipCode, city, alertTemp, alertWind, alertHumidity)
 
+	ae.mu.Lock()
 	ae.alertRules[zipCode] = rule
+	ae.mu.Unlock()
+
 	log.Printf("📋 Added alert rule for %s (%s): Temp=%.1f°C, Wind=%.1fm/s, Humidity=%d%%",
 		city, zipCode, alertTemp, alertWind, alertHumidity)
 }
 
+// ReplaceAlertRules atomically swaps the whole rule set, e.g. after the locations file is reloaded
+func (ae *AlertEvaluator) ReplaceAlertRules(rules []AlertRule) {
+	replacement := make(map[string]AlertRule, len(rules))
+	for _, rule := range rules {
+		replacement[rule.ZipCode] = rule
+	}
+
+	ae.mu.Lock()
+	previous := ae.alertRules
+	for zipCode, rule := range replacement {
+		// Keep names learned from messages for locations without a configured name
+		if old, exists := previous[zipCode]; exists && !rule.CityConfigured && !old.CityConfigured {
+			rule.City = old.City
+			replacement[zipCode] = rule
+		}
+	}
+	ae.alertRules = replacement
+	ae.mu.Unlock()
+
+	added, changed, removed := 0, 0, 0
+	for zipCode, rule := range replacement {
+		old, exists := previous[zipCode]
+		switch {
+		case !exists:
+			added++
+		case old != rule:
+			changed++
+		}
+	}
+	for zipCode := range previous {
+		if _, exists := replacement[zipCode]; !exists {
+			removed++
+		}
+	}
+
+	log.Printf("📋 Alert rules replaced: %d rules (%d added, %d changed, %d removed)", len(replacement), added, changed, removed)
+}
+
+// LearnCityName records the display name carried by an incoming message for a location without a configured name
+func (ae *AlertEvaluator) LearnCityName(zipCode, city string) {
+	if city == "" {
+		return
+	}
+
+	ae.mu.Lock()
+	defer ae.mu.Unlock()
+
+	rule, exists := ae.alertRules[zipCode]
+	if !exists || rule.CityConfigured || rule.City == city {
+		return
+	}
+
+	rule.City = city
+	ae.alertRules[zipCode] = rule
+	log.Printf("🏙️ Learned city name for %s: %s", zipCode, city)
+}
+
+// CityName returns the configured or learned display name of a location, or "" if none is known
+func (ae *AlertEvaluator) CityName(zipCode string) string {
+	rule, _ := ae.GetAlertRule(zipCode)
+	return rule.City
+}
+
 // EvaluateCurrentWeather evaluates current weather conditions and returns alerts
 func (ae *AlertEvaluator) EvaluateCurrentWeather(weather models.OpenWeatherResponse, zipCode string) []WeatherAlert {
 	var alerts []WeatherAlert
 
-	rule, exists := ae.alertRules[zipCode]
+	rule, exists := ae.GetAlertRule(zipCode)
 	if !exists {
 		log.Printf("⚠️ No alert rule found for zip code: %s", zipCode)
 		return alerts
 	}
+	if rule.City == "" {
+		rule.City = zipCode // Name not configured or learned yet
+	}
 
 	// Temperature alerts
 	alerts = append(alerts, ae.evaluateTemperatureAlerts(weather, rule)...)
@@ -95,7 +174,7 @@ func (ae *AlertEvaluator) EvaluateCurrentWeather(weather models.OpenWeatherRespo
 func (ae *AlertEvaluator) EvaluateHourlyWeather(hourly models.ForecastItem, zipCode string) []WeatherAlert {
 	var alerts []WeatherAlert
 
-	_, exists := ae.alertRules[zipCode]
+	_, exists := ae.GetAlertRule(zipCode)
 	if !exists {
 		return alerts
 	}
@@ -117,8 +196,10 @@ func (ae *AlertEvaluator) EvaluateHourlyWeather(hourly models.ForecastItem, zipC
 		Wind: struct {
 			Speed float32 `json:"speed"`
 			Deg   int     `json:"deg"`
+			Gust  float32 `json:"gust,omitempty"`
 		}{
 			Speed: hourly.Wind.Speed,
+			Gust:  hourly.Wind.Gust,
 		},
 		Weather: hourly.Weather,
 	}
@@ -283,13 +364,23 @@ func (ae *AlertEvaluator) evaluateWeatherConditionAlerts(weather models.OpenWeat
 	return alerts
 }
 
-// GetAlertRules returns all configured alert rules
+// GetAlertRules returns a copy of all configured alert rules
 func (ae *AlertEvaluator) GetAlertRules() map[string]AlertRule {
-	return ae.alertRules
+	ae.mu.RLock()
+	defer ae.mu.RUnlock()
+
+	rules := make(map[string]AlertRule, len(ae.alertRules))
+	for zipCode, rule := range ae.alertRules {
+		rules[zipCode] = rule
+	}
+	return rules
 }
 
 // GetAlertRule returns alert rule for a specific zip code
 func (ae *AlertEvaluator) GetAlertRule(zipCode string) (AlertRule, bool) {
+	ae.mu.RLock()
+	defer ae.mu.RUnlock()
+
 	rule, exists := ae.alertRules[zipCode]
 	return rule, exists
 }
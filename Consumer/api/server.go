@@ -1,7 +1,9 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -120,13 +122,34 @@ func (api *WeatherAPI) sendErrorResponse(w http.ResponseWriter, message string,
 	json.NewEncoder(w).Encode(response)
 }
 
-// StartServer starts the HTTP server
-func (api *WeatherAPI) StartServer(port string) {
+// StartServer runs the HTTP server until ctx is cancelled, then shuts it down,
+// giving in-flight requests up to shutdownTimeout to complete
+func (api *WeatherAPI) StartServer(ctx context.Context, port string, shutdownTimeout time.Duration) error {
 	log.Printf("🌐 Starting Weather Consumer API server on port %s", port)
 	log.Printf("📊 Metrics available at http://localhost:%s/metrics", port)
 	log.Printf("🔍 Health check at http://localhost:%s/health", port)
 
-	if err := http.ListenAndServe(":"+port, api.router); err != nil {
-		log.Fatalf("❌ Failed to start server: %v", err)
+	server := &http.Server{
+		Addr:    ":" + port,
+		Handler: api.router,
 	}
+
+	shutdownDone := make(chan error, 1)
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		shutdownDone <- server.Shutdown(shutdownCtx)
+	}()
+
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to start API server: %v", err)
+	}
+
+	if err := <-shutdownDone; err != nil {
+		return fmt.Errorf("API server did not shut down cleanly: %v", err)
+	}
+	log.Println("🌐 Weather Consumer API server stopped")
+	return nil
 }
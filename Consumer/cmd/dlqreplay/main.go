@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/abhijeet1999/weather/Consumer/kafka"
+	"github.com/abhijeet1999/weather/kafkaconfig"
+)
+
+func main() {
+	// Brokers, TLS and SASL settings come from the same environment variables as the consumer
+	kafkaConfig := kafkaconfig.FromEnv("localhost:9092", "weather-dlq-replay")
+
+	brokers := flag.String("brokers", strings.Join(kafkaConfig.Brokers, ","), "comma-separated Kafka brokers")
+	deadLetterTopic := flag.String("dlq", getEnvOrDefault("KAFKA_DEAD_LETTER_TOPIC", getEnvOrDefault("KAFKA_TOPIC", "weather_data")+"_dead_letter"), "dead-letter topic to replay")
+	topic := flag.String("topic", "", "topic to replay into (default: each message's source topic)")
+	groupID := flag.String("group", "weather-dlq-replay", "consumer group that tracks replay progress")
+	maxAttempts := flag.Int("max-attempts", 0, "pass over messages that have been dead-lettered this many times (default: no limit)")
+	limit := flag.Int("limit", 0, "maximum number of messages to replay (default: all)")
+	idleTimeout := flag.Duration("idle-timeout", 10*time.Second, "stop once no message arrives for this long")
+	dryRun := flag.Bool("dry-run", false, "log what would be replayed without writing or committing")
+	flag.Parse()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	kafkaConfig.Brokers = kafkaconfig.ParseBrokers(*brokers)
+	kafkaConn, err := kafkaconfig.NewConnection(kafkaConfig)
+	if err != nil {
+		log.Fatalf("❌ Invalid Kafka connection settings: %v", err)
+	}
+
+	log.Printf("🔁 Replaying dead letters from %s via %s", *deadLetterTopic, kafkaConn)
+
+	summary, err := kafka.ReplayDeadLetters(ctx, kafka.ReplayConfig{
+		Connection:      kafkaConn,
+		DeadLetterTopic: *deadLetterTopic,
+		GroupID:         *groupID,
+		Topic:           *topic,
+		MaxAttempts:     *maxAttempts,
+		Limit:           *limit,
+		IdleTimeout:     *idleTimeout,
+		DryRun:          *dryRun,
+	})
+	log.Printf("📋 Replay summary: %d replayed, %d skipped", summary.Replayed, summary.Skipped)
+	if err != nil {
+		log.Fatalf("❌ Replay stopped: %v", err)
+	}
+}
+
+// getEnvOrDefault returns environment variable value or default
+func getEnvOrDefault(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,94 @@
+package kafka
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// partitionKey identifies a topic partition
+type partitionKey struct {
+	topic     string
+	partition int
+}
+
+// messageCommitter commits consumed offsets; implemented by *kafka.Reader
+type messageCommitter interface {
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+// offsetCommitter batches offset commits: messages are marked done once fully handled, and the latest
+// done message of each partition is committed on an interval. A crash re-delivers at most one interval of messages.
+type offsetCommitter struct {
+	reader   messageCommitter
+	interval time.Duration
+
+	mu      sync.Mutex
+	pending map[partitionKey]kafka.Message // Latest done message per partition, not yet committed
+}
+
+// newOffsetCommitter creates a new offsetCommitter instance
+func newOffsetCommitter(reader messageCommitter, interval time.Duration) *offsetCommitter {
+	return &offsetCommitter{
+		reader:   reader,
+		interval: interval,
+		pending:  make(map[partitionKey]kafka.Message),
+	}
+}
+
+// MarkDone records that a message and everything before it in its partition has been handled
+func (c *offsetCommitter) MarkDone(msg kafka.Message) {
+	key := partitionKey{topic: msg.Topic, partition: msg.Partition}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if pending, exists := c.pending[key]; !exists || msg.Offset > pending.Offset {
+		c.pending[key] = msg
+	}
+}
+
+// Run commits pending offsets every interval until ctx is cancelled
+func (c *offsetCommitter) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.Flush(ctx); err != nil {
+				log.Printf("⚠️ Failed to commit offsets, will retry: %v", err)
+			}
+		}
+	}
+}
+
+// Flush commits pending offsets now; offsets that fail to commit stay pending for the next attempt
+func (c *offsetCommitter) Flush(ctx context.Context) error {
+	c.mu.Lock()
+	if len(c.pending) == 0 {
+		c.mu.Unlock()
+		return nil
+	}
+	batch := make([]kafka.Message, 0, len(c.pending))
+	for _, msg := range c.pending {
+		batch = append(batch, msg)
+	}
+	c.pending = make(map[partitionKey]kafka.Message)
+	c.mu.Unlock()
+
+	if err := c.reader.CommitMessages(ctx, batch...); err != nil {
+		// Put the batch back unless newer messages were marked done meanwhile
+		for _, msg := range batch {
+			c.MarkDone(msg)
+		}
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// fakeCommitter records committed offsets and fails while err is set
+type fakeCommitter struct {
+	mu        sync.Mutex
+	err       error
+	committed map[partitionKey]int64
+}
+
+func (f *fakeCommitter) CommitMessages(ctx context.Context, msgs ...kafka.Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.err != nil {
+		return f.err
+	}
+	if f.committed == nil {
+		f.committed = make(map[partitionKey]int64)
+	}
+	for _, msg := range msgs {
+		f.committed[partitionKey{topic: msg.Topic, partition: msg.Partition}] = msg.Offset
+	}
+	return nil
+}
+
+func TestOffsetCommitterFlush(t *testing.T) {
+	tests := []struct {
+		name string
+		done []kafka.Message
+		want map[partitionKey]int64
+	}{
+		{
+			name: "nothing done",
+		},
+		{
+			name: "latest offset per partition",
+			done: []kafka.Message{
+				{Topic: "weather", Partition: 0, Offset: 4},
+				{Topic: "weather", Partition: 0, Offset: 5},
+				{Topic: "weather", Partition: 1, Offset: 9},
+			},
+			want: map[partitionKey]int64{{"weather", 0}: 5, {"weather", 1}: 9},
+		},
+		{
+			name: "an older offset marked later does not move the partition back",
+			done: []kafka.Message{
+				{Topic: "weather", Partition: 0, Offset: 7},
+				{Topic: "weather", Partition: 0, Offset: 3},
+			},
+			want: map[partitionKey]int64{{"weather", 0}: 7},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := &fakeCommitter{}
+			c := newOffsetCommitter(reader, 0)
+			for _, msg := range tt.done {
+				c.MarkDone(msg)
+			}
+
+			if err := c.Flush(context.Background()); err != nil {
+				t.Fatalf("Flush: %v", err)
+			}
+			if len(reader.committed) != len(tt.want) {
+				t.Fatalf("committed = %v, want %v", reader.committed, tt.want)
+			}
+			for key, offset := range tt.want {
+				if reader.committed[key] != offset {
+					t.Errorf("committed %v = %d, want %d", key, reader.committed[key], offset)
+				}
+			}
+			if len(c.pending) != 0 {
+				t.Errorf("pending after Flush = %v, want none", c.pending)
+			}
+		})
+	}
+}
+
+func TestOffsetCommitterRetriesFailedCommits(t *testing.T) {
+	reader := &fakeCommitter{err: errors.New("broker unavailable")}
+	c := newOffsetCommitter(reader, 0)
+	c.MarkDone(kafka.Message{Topic: "weather", Partition: 0, Offset: 4})
+	c.MarkDone(kafka.Message{Topic: "weather", Partition: 1, Offset: 2})
+
+	if err := c.Flush(context.Background()); err == nil {
+		t.Fatal("Flush succeeded while commits fail")
+	}
+
+	// A message marked done after the failure supersedes the batch that was put back
+	c.MarkDone(kafka.Message{Topic: "weather", Partition: 0, Offset: 6})
+	reader.err = nil
+	if err := c.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	var got []int64
+	for _, offset := range reader.committed {
+		got = append(got, offset)
+	}
+	sort.Slice(got, func(i, j int) bool { return got[i] < got[j] })
+	if len(got) != 2 || got[0] != 2 || got[1] != 6 {
+		t.Errorf("committed offsets = %v, want [2 6]", got)
+	}
+}
@@ -2,129 +2,355 @@ package kafka
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/abhijeet1999/weather/Consumer/alerts"
 	"github.com/abhijeet1999/weather/Consumer/prometheus"
+	"github.com/abhijeet1999/weather/Producer/utils"
+	"github.com/abhijeet1999/weather/codec"
+	"github.com/abhijeet1999/weather/kafkaconfig"
 	"github.com/abhijeet1999/weather/models"
 	"github.com/segmentio/kafka-go"
 )
 
-// KafkaConsumer handles consuming weather data from Kafka
+// Config holds Kafka consumer settings
+type Config struct {
+	Connection      *kafkaconfig.Connection
+	Topics          kafkaconfig.Topics // Where the producer routes each message type
+	MessageTypes    []string           // Message types to consume; empty means all
+	GroupID         string
+	DeadLetterTopic string        // Messages that cannot be processed are republished here; empty drops them
+	CommitInterval  time.Duration // How often offsets of processed messages are committed
+	OffsetsFile     string        // Where the highest processed offset per partition is saved; empty keeps it in memory
+	DedupWindow     time.Duration // How long message IDs are remembered to drop duplicates
+}
+
+// KafkaConsumer handles consuming weather data from Kafka.
+// Offsets are committed only after a message's metrics and alerts are applied (or it is dead-lettered),
+// so messages are processed at least once; redelivered messages (by offset) and republished observations (by message ID)
+// are recognized and skipped.
 type KafkaConsumer struct {
 	reader         *kafka.Reader
-	topic          string
+	routes         map[string]map[string]bool // Message types consumed from each subscribed topic
+	ignored        map[string]bool            // Topic and message type pairs already logged as ignored
 	groupID        string
 	metrics        *prometheus.WeatherMetrics
 	alertEvaluator *alerts.AlertEvaluator
+	deadLetter     *DeadLetterWriter // Nil when no dead-letter topic is configured
+	schemas        *codec.Registry   // Nil when no schema registry is loaded; binary messages are then rejected
+	committer      *offsetCommitter
+	processed      *processedOffsets
+	recent         *recentMessages // IDs of recently processed messages, kept in memory only
 }
 
-// NewKafkaConsumer creates a new Kafka consumer instance
-func NewKafkaConsumer(bootstrapServers, topic, groupID string, alertEvaluator *alerts.AlertEvaluator) (*KafkaConsumer, error) {
+// NewKafkaConsumer creates a new Kafka consumer instance subscribed to the topics that carry the configured
+// message types. Binary-encoded messages are decoded with the schemas in registry.
+func NewKafkaConsumer(config Config, registry *codec.Registry, alertEvaluator *alerts.AlertEvaluator) (*KafkaConsumer, error) {
+	if config.CommitInterval <= 0 {
+		config.CommitInterval = 5 * time.Second
+	}
+	if config.DedupWindow <= 0 {
+		config.DedupWindow = 48 * time.Hour
+	}
+	if len(config.MessageTypes) == 0 {
+		config.MessageTypes = kafkaconfig.MessageTypes
+	}
+
+	routes := make(map[string]map[string]bool)
+	var subscriptions []string
+	for topic, messageTypes := range config.Topics.Subscriptions(config.MessageTypes) {
+		if topic == "" {
+			return nil, fmt.Errorf("no topic configured for %s messages", strings.Join(messageTypes, ", "))
+		}
+		routes[topic] = make(map[string]bool, len(messageTypes))
+		for _, messageType := range messageTypes {
+			if !kafkaconfig.IsMessageType(messageType) {
+				return nil, fmt.Errorf("unknown message type %q", messageType)
+			}
+			routes[topic][messageType] = true
+		}
+		subscriptions = append(subscriptions, fmt.Sprintf("%s (%s)", topic, strings.Join(messageTypes, ", ")))
+	}
+	topics := make([]string, 0, len(routes))
+	for topic := range routes {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+	sort.Strings(subscriptions)
+
 	reader := kafka.NewReader(kafka.ReaderConfig{
-		Brokers:     []string{bootstrapServers},
-		Topic:       topic,
-		GroupID:     groupID,
+		Brokers:     config.Connection.Brokers(),
+		GroupTopics: topics,
+		GroupID:     config.GroupID,
 		MinBytes:    10e3, // 10KB
 		MaxBytes:    10e6, // 10MB
 		StartOffset: kafka.FirstOffset,
-		Dialer: &kafka.Dialer{
-			Timeout: 10 * time.Second,
-		},
+		Dialer:      config.Connection.Dialer(),
 	})
 
+	processed := newProcessedOffsets(config.OffsetsFile)
+	if err := processed.Load(); err != nil {
+		log.Printf("⚠️ Starting without processed offsets, redelivered messages may be processed again: %v", err)
+	}
+
 	metrics := prometheus.NewWeatherMetrics()
 
-	log.Printf("📥 Kafka consumer connected to %s, topic: %s, group: %s", bootstrapServers, topic, groupID)
+	var deadLetter *DeadLetterWriter
+	if config.DeadLetterTopic != "" {
+		deadLetter = NewDeadLetterWriter(config.Connection, config.DeadLetterTopic)
+	}
+
+	log.Printf("📥 Kafka consumer connected to %s, topics: %s, group: %s, commit interval: %s",
+		config.Connection, strings.Join(subscriptions, ", "), config.GroupID, config.CommitInterval)
 
 	return &KafkaConsumer{
 		reader:         reader,
-		topic:          topic,
-		groupID:        groupID,
+		routes:         routes,
+		ignored:        make(map[string]bool),
+		groupID:        config.GroupID,
 		metrics:        metrics,
 		alertEvaluator: alertEvaluator,
+		deadLetter:     deadLetter,
+		schemas:        registry,
+		committer:      newOffsetCommitter(reader, config.CommitInterval),
+		processed:      processed,
+		recent:         newRecentMessages(config.DedupWindow),
 	}, nil
 }
 
-// WeatherMessage represents the message structure received from Kafka
-type WeatherMessage struct {
-	Timestamp   time.Time                           `json:"timestamp"`
-	ZipCode     string                              `json:"zip_code"`
-	City        string                              `json:"city"`
-	Country     string                              `json:"country"`
-	Current     *models.OpenWeatherResponse         `json:"current,omitempty"`
-	Forecast    *models.OpenWeatherForecastResponse `json:"forecast,omitempty"`
-	Hourly      *models.ForecastItem                `json:"hourly,omitempty"`
-	Daily       *DailyWeatherData                   `json:"daily,omitempty"`
-	MessageType string                              `json:"message_type"` // "current", "forecast", "hourly", "daily"
-}
-
-// DailyWeatherData represents daily weather summary
-type DailyWeatherData struct {
-	Day         int     `json:"day"`
-	Date        string  `json:"date"`
-	TempMin     float32 `json:"temp_min"`
-	TempMax     float32 `json:"temp_max"`
-	TempAvg     float32 `json:"temp_avg"`
-	Humidity    int     `json:"humidity"`
-	WindSpeed   float32 `json:"wind_speed"`
-	Description string  `json:"description"`
-	Icon        string  `json:"icon"`
-}
-
-// StartConsuming starts consuming messages from Kafka
-func (kc *KafkaConsumer) StartConsuming() {
+// StartConsuming consumes messages from Kafka until ctx is cancelled or the consumer is closed.
+// On shutdown the in-flight message is finished and processed offsets are committed before it returns.
+func (kc *KafkaConsumer) StartConsuming(ctx context.Context) {
 	log.Println("🔄 Starting Kafka consumer...")
 
-	ctx := context.Background()
+	// Commit periodically until the loop stops; the final commit below waits for the last periodic one
+	commitCtx, stopCommits := context.WithCancel(ctx)
+	var committing sync.WaitGroup
+	committing.Add(1)
+	go func() {
+		defer committing.Done()
+		kc.committer.Run(commitCtx)
+	}()
+
 	for {
-		msg, err := kc.reader.ReadMessage(ctx)
+		msg, err := kc.reader.FetchMessage(ctx)
 		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, io.EOF) {
+				break // Shutting down or reader closed
+			}
 			log.Printf("❌ Error reading message: %v", err)
 			continue
 		}
 
-		// Process the message
-		err = kc.processMessage(msg)
-		if err != nil {
-			log.Printf("❌ Error processing message: %v", err)
+		if err := kc.handleMessage(ctx, msg); err != nil {
+			// Leave the offset uncommitted so the message is delivered again after a restart
+			log.Printf("❌ %v", err)
+			break
+		}
+		kc.committer.MarkDone(msg)
+	}
+
+	stopCommits()
+	committing.Wait()
+	kc.flushOffsets()
+	log.Println("✅ Kafka consumer stopped")
+}
+
+// handleMessage processes a message, dead-lettering it if processing fails.
+// It returns an error only if the message was neither processed nor dead-lettered.
+func (kc *KafkaConsumer) handleMessage(ctx context.Context, msg kafka.Message) error {
+	if kc.processed.Seen(msg) {
+		log.Printf("⏭️ Skipping redelivered message %s[%d]@%d", msg.Topic, msg.Partition, msg.Offset)
+		return nil
+	}
+
+	if err := kc.processMessage(msg); err != nil {
+		log.Printf("❌ Error processing message: %v", err)
+
+		if kc.deadLetter != nil {
+			if err := kc.sendToDeadLetter(ctx, msg, err); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := kc.processed.Record(msg); err != nil {
+		log.Printf("⚠️ Failed to persist processed offset: %v", err)
+	}
+	return nil
+}
+
+// sendToDeadLetter republishes a failed message, retrying with backoff until it succeeds or ctx is cancelled.
+// Later messages wait meanwhile, since committing past the failed message would lose it.
+func (kc *KafkaConsumer) sendToDeadLetter(ctx context.Context, msg kafka.Message, reason error) error {
+	backoff := time.Second
+	for {
+		err := kc.deadLetter.Send(msg, reason)
+		if err == nil {
+			return nil
+		}
+
+		log.Printf("⚠️ %v; retrying in %s", err, backoff)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("stopped dead-lettering %s[%d]@%d: %v", msg.Topic, msg.Partition, msg.Offset, ctx.Err())
+		case <-time.After(backoff):
+		}
+		if backoff < 30*time.Second {
+			backoff *= 2
 		}
 	}
 }
 
 // processMessage processes a single Kafka message
 func (kc *KafkaConsumer) processMessage(msg kafka.Message) error {
-	var weatherMsg WeatherMessage
+	// Skip message types this consumer does not want without decoding them
+	if !kc.accepts(msg.Topic, headerValue(msg, "message_type")) {
+		return nil
+	}
 
-	// Deserialize JSON message
-	err := json.Unmarshal(msg.Value, &weatherMsg)
+	// Deserialize the message with its codec, upgrading older schema versions
+	weatherMsg, err := decodeMessage(msg, kc.schemas)
 	if err != nil {
-		return fmt.Errorf("failed to unmarshal message: %v", err)
+		return err
+	}
+	if !kc.accepts(msg.Topic, weatherMsg.MessageType) {
+		return nil
+	}
+
+	// The same observation may be published more than once; its ID is stable, unlike the offset
+	if weatherMsg.MessageID != "" && kc.recent.contains(weatherMsg.MessageID) {
+		log.Printf("⏭️ Skipping duplicate %s message %s for %s", weatherMsg.MessageType, weatherMsg.MessageID, weatherMsg.ZipCode)
+		return nil
+	}
+
+	// Learn display names from messages; configured names take precedence
+	if kc.alertEvaluator != nil {
+		kc.alertEvaluator.LearnCityName(weatherMsg.ZipCode, weatherMsg.City)
+		if city := kc.alertEvaluator.CityName(weatherMsg.ZipCode); city != "" {
+			weatherMsg.City = city
+		}
 	}
+	if weatherMsg.City == "" {
+		weatherMsg.City = weatherMsg.ZipCode
+	}
+
+	// Metrics and alert thresholds are in °C and m/s
+	normalizeToSI(&weatherMsg)
 
 	log.Printf("📥 Received weather data: %s (%s) - %s",
 		weatherMsg.City, weatherMsg.ZipCode, weatherMsg.MessageType)
 
-	// Process based on message type
+	if err := kc.dispatch(weatherMsg); err != nil {
+		return err
+	}
+
+	// Only processed messages count as seen, so a failed message is applied when replayed from the dead-letter topic
+	if weatherMsg.MessageID != "" {
+		kc.recent.add(weatherMsg.MessageID)
+	}
+	return nil
+}
+
+// dispatch processes a decoded message based on its message type
+func (kc *KafkaConsumer) dispatch(weatherMsg models.WeatherMessage) error {
 	switch weatherMsg.MessageType {
-	case "current":
+	case models.MessageTypeCurrent:
 		return kc.processCurrentWeather(weatherMsg)
-	case "forecast":
+	case models.MessageTypeForecast:
 		return kc.processForecastWeather(weatherMsg)
-	case "hourly":
+	case models.MessageTypeHourly:
 		return kc.processHourlyWeather(weatherMsg)
-	case "daily":
+	case models.MessageTypeDaily:
 		return kc.processDailyWeather(weatherMsg)
 	default:
 		return fmt.Errorf("unknown message type: %s", weatherMsg.MessageType)
 	}
 }
 
+// accepts reports whether a message type is consumed from a topic. Known message types that are not routed to
+// the topic are ignored, e.g. forecasts on a shared topic when only current conditions are consumed; unknown
+// types are accepted so they fail processing and are dead-lettered.
+func (kc *KafkaConsumer) accepts(topic, messageType string) bool {
+	if messageType == "" || !kafkaconfig.IsMessageType(messageType) || kc.routes[topic][messageType] {
+		return true
+	}
+
+	if key := topic + "/" + messageType; !kc.ignored[key] {
+		kc.ignored[key] = true
+		log.Printf("⏭️ Ignoring %s messages on topic %s: not consumed from this topic", messageType, topic)
+	}
+	return false
+}
+
+// normalizeToSI converts the temperatures and wind speeds of a message to °C and m/s; precipitation is always in mm
+func normalizeToSI(msg *models.WeatherMessage) {
+	units := utils.NormalizeUnits(msg.Units)
+	if units == utils.UnitMetric {
+		msg.Units = utils.UnitMetric
+		return
+	}
+
+	if msg.Current != nil {
+		current := *msg.Current
+		current.Main.Temp = utils.ToCelsius(current.Main.Temp, units)
+		current.Main.FeelsLike = utils.ToCelsius(current.Main.FeelsLike, units)
+		current.Main.TempMin = utils.ToCelsius(current.Main.TempMin, units)
+		current.Main.TempMax = utils.ToCelsius(current.Main.TempMax, units)
+		current.Wind.Speed = utils.ToMetersPerSecond(current.Wind.Speed, units)
+		current.Wind.Gust = utils.ToMetersPerSecond(current.Wind.Gust, units)
+		msg.Current = &current
+	}
+
+	if msg.Forecast != nil {
+		forecast := *msg.Forecast
+		forecast.List = make([]models.ForecastItem, len(msg.Forecast.List))
+		for i, item := range msg.Forecast.List {
+			forecast.List[i] = forecastItemToSI(item, units)
+		}
+		msg.Forecast = &forecast
+	}
+
+	if msg.Hourly != nil {
+		hourly := forecastItemToSI(*msg.Hourly, units)
+		msg.Hourly = &hourly
+	}
+
+	if msg.Daily != nil {
+		daily := *msg.Daily
+		daily.TempMin = utils.ToCelsius(daily.TempMin, units)
+		daily.TempMax = utils.ToCelsius(daily.TempMax, units)
+		daily.TempAvg = utils.ToCelsius(daily.TempAvg, units)
+		daily.WindSpeed = utils.ToMetersPerSecond(daily.WindSpeed, units)
+		daily.FeelsLikeMin = utils.ToCelsius(daily.FeelsLikeMin, units)
+		daily.FeelsLikeMax = utils.ToCelsius(daily.FeelsLikeMax, units)
+		daily.WindGust = utils.ToMetersPerSecond(daily.WindGust, units)
+		msg.Daily = &daily
+	}
+
+	msg.Units = utils.UnitMetric
+}
+
+// forecastItemToSI converts the temperatures and wind speed of a forecast item to °C and m/s
+func forecastItemToSI(item models.ForecastItem, units string) models.ForecastItem {
+	item.Main.Temp = utils.ToCelsius(item.Main.Temp, units)
+	item.Main.FeelsLike = utils.ToCelsius(item.Main.FeelsLike, units)
+	item.Main.TempMin = utils.ToCelsius(item.Main.TempMin, units)
+	item.Main.TempMax = utils.ToCelsius(item.Main.TempMax, units)
+	item.Wind.Speed = utils.ToMetersPerSecond(item.Wind.Speed, units)
+	item.Wind.Gust = utils.ToMetersPerSecond(item.Wind.Gust, units)
+	return item
+}
+
 // processCurrentWeather processes current weather data
-func (kc *KafkaConsumer) processCurrentWeather(msg WeatherMessage) error {
+func (kc *KafkaConsumer) processCurrentWeather(msg models.WeatherMessage) error {
 	if msg.Current == nil {
 		return fmt.Errorf("current weather data is nil")
 	}
@@ -151,7 +377,7 @@ func (kc *KafkaConsumer) processCurrentWeather(msg WeatherMessage) error {
 }
 
 // processForecastWeather processes forecast weather data
-func (kc *KafkaConsumer) processForecastWeather(msg WeatherMessage) error {
+func (kc *KafkaConsumer) processForecastWeather(msg models.WeatherMessage) error {
 	if msg.Forecast == nil {
 		return fmt.Errorf("forecast weather data is nil")
 	}
@@ -176,7 +402,7 @@ func (kc *KafkaConsumer) processForecastWeather(msg WeatherMessage) error {
 }
 
 // processHourlyWeather processes individual hourly weather data
-func (kc *KafkaConsumer) processHourlyWeather(msg WeatherMessage) error {
+func (kc *KafkaConsumer) processHourlyWeather(msg models.WeatherMessage) error {
 	if msg.Hourly == nil {
 		return fmt.Errorf("hourly weather data is nil")
 	}
@@ -204,30 +430,47 @@ func (kc *KafkaConsumer) processHourlyWeather(msg WeatherMessage) error {
 }
 
 // processDailyWeather processes daily weather summary data
-func (kc *KafkaConsumer) processDailyWeather(msg WeatherMessage) error {
+func (kc *KafkaConsumer) processDailyWeather(msg models.WeatherMessage) error {
 	if msg.Daily == nil {
 		return fmt.Errorf("daily weather data is nil")
 	}
 
-	// Update Prometheus metrics for daily data
-	// Use average temperature for daily metrics
-	kc.metrics.UpdateCurrentWeatherMetrics(
+	// Update the daily forecast gauges of this day offset; current weather gauges only follow current messages
+	kc.metrics.UpdateDailyWeatherMetrics(
 		msg.City,
+		msg.ZipCode,
+		msg.Daily.Day,
+		msg.Daily.TempMin,
 		msg.Daily.TempAvg,
+		msg.Daily.TempMax,
 		float32(msg.Daily.Humidity),
 		msg.Daily.WindSpeed,
-		1013.25, // Default pressure for daily data
 	)
 
-	log.Printf("📊 Updated daily metrics for %s (Day %d): TempAvg=%.1f°C, TempMin=%.1f°C, TempMax=%.1f°C, Humidity=%d%%, Wind=%.1fm/s",
-		msg.City, msg.Daily.Day, msg.Daily.TempAvg, msg.Daily.TempMin, msg.Daily.TempMax, msg.Daily.Humidity, msg.Daily.WindSpeed)
+	log.Printf("📊 Updated daily metrics for %s (Day %d): TempAvg=%.1f°C, TempMin=%.1f°C, TempMax=%.1f°C, Humidity=%d%%, Wind=%.1fm/s, Gust=%.1fm/s, Rain=%.1fmm, Snow=%.1fmm, Pop=%.0f%%, %s",
+		msg.City, msg.Daily.Day, msg.Daily.TempAvg, msg.Daily.TempMin, msg.Daily.TempMax, msg.Daily.Humidity, msg.Daily.WindSpeed,
+		msg.Daily.WindGust, msg.Daily.Rain, msg.Daily.Snow, msg.Daily.Pop*100, msg.Daily.Description)
 
 	return nil
 }
 
 // Close closes the Kafka consumer
 func (kc *KafkaConsumer) Close() {
+	kc.flushOffsets()
 	kc.reader.Close()
+	if kc.deadLetter != nil {
+		kc.deadLetter.Close()
+	}
+}
+
+// flushOffsets commits the offsets of all processed messages
+func (kc *KafkaConsumer) flushOffsets() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := kc.committer.Flush(ctx); err != nil {
+		log.Printf("⚠️ Failed to commit offsets: %v", err)
+	}
 }
 
 // GetMetrics returns the Prometheus metrics instance
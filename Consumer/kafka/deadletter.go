@@ -0,0 +1,232 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/abhijeet1999/weather/kafkaconfig"
+	"github.com/segmentio/kafka-go"
+)
+
+// Headers added to dead-lettered messages on top of their original headers
+const (
+	HeaderDeadLetterReason   = "dead_letter_reason"
+	HeaderDeadLetterAttempts = "dead_letter_attempts" // Times the message has been dead-lettered, kept across replays
+	HeaderDeadLetterTime     = "dead_letter_time"
+	HeaderSourceTopic        = "source_topic"
+	HeaderSourcePartition    = "source_partition"
+	HeaderSourceOffset       = "source_offset"
+)
+
+// deadLetterHeaders are the headers that describe a single trip to the dead-letter topic
+var deadLetterHeaders = map[string]bool{
+	HeaderDeadLetterReason:   true,
+	HeaderDeadLetterAttempts: true,
+	HeaderDeadLetterTime:     true,
+	HeaderSourceTopic:        true,
+	HeaderSourcePartition:    true,
+	HeaderSourceOffset:       true,
+}
+
+// DeadLetterWriter republishes messages the consumer cannot process to a dead-letter topic so they can be
+// inspected and replayed
+type DeadLetterWriter struct {
+	writer *kafka.Writer
+	topic  string
+}
+
+// NewDeadLetterWriter creates a new DeadLetterWriter instance
+func NewDeadLetterWriter(conn *kafkaconfig.Connection, topic string) *DeadLetterWriter {
+	writer := &kafka.Writer{
+		Addr:                   conn.Addr(),
+		Transport:              conn.Transport(),
+		Topic:                  topic,
+		Balancer:               &kafka.LeastBytes{},
+		AllowAutoTopicCreation: true,
+	}
+
+	log.Printf("☠️ Dead-letter topic: %s", topic)
+
+	return &DeadLetterWriter{
+		writer: writer,
+		topic:  topic,
+	}
+}
+
+// Send republishes a message with its original key, value and headers plus the reason it failed,
+// how many times it has been dead-lettered and where it was read from
+func (d *DeadLetterWriter) Send(msg kafka.Message, reason error) error {
+	attempts := DeadLetterAttempts(msg) + 1
+
+	headers := withoutDeadLetterHeaders(msg.Headers)
+	headers = append(headers,
+		kafka.Header{Key: HeaderDeadLetterReason, Value: []byte(reason.Error())},
+		kafka.Header{Key: HeaderDeadLetterAttempts, Value: []byte(strconv.Itoa(attempts))},
+		kafka.Header{Key: HeaderDeadLetterTime, Value: []byte(time.Now().UTC().Format(time.RFC3339))},
+		kafka.Header{Key: HeaderSourceTopic, Value: []byte(msg.Topic)},
+		kafka.Header{Key: HeaderSourcePartition, Value: []byte(strconv.Itoa(msg.Partition))},
+		kafka.Header{Key: HeaderSourceOffset, Value: []byte(strconv.FormatInt(msg.Offset, 10))},
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err := d.writer.WriteMessages(ctx, kafka.Message{
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: headers,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write to dead-letter topic %s: %v", d.topic, err)
+	}
+
+	log.Printf("☠️ Sent message from %s[%d]@%d to dead-letter topic %s (attempt %d): %v",
+		msg.Topic, msg.Partition, msg.Offset, d.topic, attempts, reason)
+	return nil
+}
+
+// Close closes the dead-letter writer
+func (d *DeadLetterWriter) Close() {
+	d.writer.Close()
+}
+
+// DeadLetterAttempts returns how many times a message has already been dead-lettered
+func DeadLetterAttempts(msg kafka.Message) int {
+	attempts, err := strconv.Atoi(headerValue(msg, HeaderDeadLetterAttempts))
+	if err != nil || attempts < 0 {
+		return 0
+	}
+	return attempts
+}
+
+// withoutDeadLetterHeaders returns a copy of headers with the per-trip dead-letter headers removed
+func withoutDeadLetterHeaders(headers []kafka.Header) []kafka.Header {
+	kept := make([]kafka.Header, 0, len(headers)+len(deadLetterHeaders))
+	for _, header := range headers {
+		if !deadLetterHeaders[header.Key] {
+			kept = append(kept, header)
+		}
+	}
+	return kept
+}
+
+// ReplayConfig holds settings for replaying a dead-letter topic
+type ReplayConfig struct {
+	Connection      *kafkaconfig.Connection
+	DeadLetterTopic string
+	GroupID         string        // Consumer group that tracks replay progress, so replayed messages are not replayed twice
+	Topic           string        // Destination topic; empty means each message's source topic
+	MaxAttempts     int           // Messages dead-lettered this many times are passed over and not replayed; 0 means no limit
+	Limit           int           // Maximum number of messages to replay; 0 means all
+	IdleTimeout     time.Duration // Replay stops once no message arrives for this long
+	DryRun          bool          // Log what would be replayed without writing or committing
+}
+
+// ReplaySummary counts the outcome of a replay
+type ReplaySummary struct {
+	Replayed int
+	Skipped  int
+}
+
+// ReplayDeadLetters republishes dead-lettered messages to their source topic with their original key, value and
+// headers. The attempt count is kept so a message that fails again is dead-lettered with a higher count.
+// Offsets are committed only after a message is written, so an interrupted replay resumes where it stopped.
+func ReplayDeadLetters(ctx context.Context, config ReplayConfig) (ReplaySummary, error) {
+	var summary ReplaySummary
+
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:     config.Connection.Brokers(),
+		Topic:       config.DeadLetterTopic,
+		GroupID:     config.GroupID,
+		StartOffset: kafka.FirstOffset,
+		Dialer:      config.Connection.Dialer(),
+	})
+	defer reader.Close()
+
+	writer := &kafka.Writer{
+		Addr:      config.Connection.Addr(),
+		Transport: config.Connection.Transport(),
+		Balancer:  &kafka.Hash{},
+	}
+	defer writer.Close()
+
+	for config.Limit <= 0 || summary.Replayed < config.Limit {
+		fetchCtx, cancel := context.WithTimeout(ctx, config.IdleTimeout)
+		msg, err := reader.FetchMessage(fetchCtx)
+		cancel()
+		if err != nil {
+			if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+				return summary, nil // Caught up
+			}
+			return summary, fmt.Errorf("failed to read dead-letter topic %s: %v", config.DeadLetterTopic, err)
+		}
+
+		topic := config.Topic
+		if topic == "" {
+			topic = headerValue(msg, HeaderSourceTopic)
+		}
+		if topic == "" {
+			log.Printf("⏭️ Skipping dead letter at offset %d: no %s header and no destination topic given", msg.Offset, HeaderSourceTopic)
+			summary.Skipped++
+			if err := commitReplay(ctx, reader, msg, config.DryRun); err != nil {
+				return summary, err
+			}
+			continue
+		}
+
+		attempts := DeadLetterAttempts(msg)
+		if config.MaxAttempts > 0 && attempts >= config.MaxAttempts {
+			log.Printf("⏭️ Skipping dead letter at offset %d: failed %d times: %s", msg.Offset, attempts, headerValue(msg, HeaderDeadLetterReason))
+			summary.Skipped++
+			if err := commitReplay(ctx, reader, msg, config.DryRun); err != nil {
+				return summary, err
+			}
+			continue
+		}
+
+		if config.DryRun {
+			log.Printf("🔍 Would replay dead letter at offset %d to %s (failed %d times): %s",
+				msg.Offset, topic, attempts, headerValue(msg, HeaderDeadLetterReason))
+			summary.Replayed++
+			continue
+		}
+
+		// Keep the attempt count so repeated failures are visible
+		headers := withoutDeadLetterHeaders(msg.Headers)
+		headers = append(headers, kafka.Header{Key: HeaderDeadLetterAttempts, Value: []byte(strconv.Itoa(attempts))})
+
+		err = writer.WriteMessages(ctx, kafka.Message{
+			Topic:   topic,
+			Key:     msg.Key,
+			Value:   msg.Value,
+			Headers: headers,
+		})
+		if err != nil {
+			return summary, fmt.Errorf("failed to replay dead letter at offset %d to %s: %v", msg.Offset, topic, err)
+		}
+
+		if err := commitReplay(ctx, reader, msg, false); err != nil {
+			return summary, err
+		}
+
+		log.Printf("🔁 Replayed dead letter at offset %d to %s", msg.Offset, topic)
+		summary.Replayed++
+	}
+
+	return summary, nil
+}
+
+// commitReplay records replay progress past a dead letter; dry runs leave progress untouched
+func commitReplay(ctx context.Context, reader *kafka.Reader, msg kafka.Message, dryRun bool) error {
+	if dryRun {
+		return nil
+	}
+	if err := reader.CommitMessages(ctx, msg); err != nil {
+		return fmt.Errorf("failed to commit dead-letter offset %d: %v", msg.Offset, err)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package kafka
+
+import "time"
+
+// recentMessages remembers the IDs of processed messages for a window, so an observation published twice is
+// applied once. It is only used by the consume loop.
+type recentMessages struct {
+	window    time.Duration
+	seen      map[string]time.Time // Message ID to when it was processed
+	lastPrune time.Time
+	now       func() time.Time
+}
+
+// newRecentMessages creates a new recentMessages instance
+func newRecentMessages(window time.Duration) *recentMessages {
+	return &recentMessages{
+		window:    window,
+		seen:      make(map[string]time.Time),
+		lastPrune: time.Now(),
+		now:       time.Now,
+	}
+}
+
+// contains reports whether a message with this ID was processed within the window
+func (r *recentMessages) contains(messageID string) bool {
+	processedAt, exists := r.seen[messageID]
+	return exists && r.now().Sub(processedAt) <= r.window
+}
+
+// add records a processed message ID, dropping IDs older than the window at most once per window fraction
+func (r *recentMessages) add(messageID string) {
+	now := r.now()
+	r.seen[messageID] = now
+
+	if now.Sub(r.lastPrune) < r.window/10 {
+		return
+	}
+	for id, processedAt := range r.seen {
+		if now.Sub(processedAt) > r.window {
+			delete(r.seen, id)
+		}
+	}
+	r.lastPrune = now
+}
@@ -0,0 +1,51 @@
+package kafka
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecentMessages(t *testing.T) {
+	start := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		elapsed time.Duration
+		lookup  string
+		want    bool
+	}{
+		{name: "processed message", elapsed: time.Minute, lookup: "a", want: true},
+		{name: "other message", elapsed: time.Minute, lookup: "b"},
+		{name: "at the end of the window", elapsed: time.Hour, lookup: "a", want: true},
+		{name: "past the window", elapsed: time.Hour + time.Second, lookup: "a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := start
+			recent := newRecentMessages(time.Hour)
+			recent.now = func() time.Time { return now }
+			recent.add("a")
+
+			now = start.Add(tt.elapsed)
+			if got := recent.contains(tt.lookup); got != tt.want {
+				t.Errorf("contains(%q) = %v, want %v", tt.lookup, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecentMessagesPrunesExpiredIDs(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	recent := newRecentMessages(time.Hour)
+	recent.now = func() time.Time { return now }
+	recent.lastPrune = now
+
+	recent.add("a")
+	now = now.Add(2 * time.Hour)
+	recent.add("b")
+
+	if _, exists := recent.seen["a"]; exists || len(recent.seen) != 1 {
+		t.Errorf("seen = %v, want only b", recent.seen)
+	}
+}
@@ -0,0 +1,109 @@
+package kafka
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// processedOffsets tracks the highest handled offset per partition in memory and in an on-disk JSON file,
+// so messages redelivered after a restart are skipped too. It is only used by the consume loop.
+type processedOffsets struct {
+	path    string
+	offsets map[partitionKey]int64
+}
+
+// processedOffset is the on-disk record format
+type processedOffset struct {
+	Topic     string `json:"topic"`
+	Partition int    `json:"partition"`
+	Offset    int64  `json:"offset"`
+}
+
+// newProcessedOffsets creates a new processedOffsets instance; an empty path keeps offsets in memory only
+func newProcessedOffsets(path string) *processedOffsets {
+	return &processedOffsets{
+		path:    path,
+		offsets: make(map[partitionKey]int64),
+	}
+}
+
+// Load reads the offsets saved by a previous run
+func (p *processedOffsets) Load() error {
+	if p.path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(p.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read processed offsets: %w", err)
+	}
+
+	var stored []processedOffset
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return fmt.Errorf("failed to parse processed offsets %s: %w", p.path, err)
+	}
+
+	for _, record := range stored {
+		p.offsets[partitionKey{topic: record.Topic, partition: record.Partition}] = record.Offset
+	}
+
+	log.Printf("📂 Loaded processed offsets for %d partitions from %s", len(stored), p.path)
+	return nil
+}
+
+// Seen reports whether a message at or after this one's offset has already been handled
+func (p *processedOffsets) Seen(msg kafka.Message) bool {
+	last, seen := p.offsets[partitionKey{topic: msg.Topic, partition: msg.Partition}]
+	return seen && msg.Offset <= last
+}
+
+// Record marks a message as handled and persists the offsets
+func (p *processedOffsets) Record(msg kafka.Message) error {
+	p.offsets[partitionKey{topic: msg.Topic, partition: msg.Partition}] = msg.Offset
+	return p.save()
+}
+
+// save writes the offsets to disk atomically
+func (p *processedOffsets) save() error {
+	if p.path == "" {
+		return nil
+	}
+
+	stored := make([]processedOffset, 0, len(p.offsets))
+	for key, offset := range p.offsets {
+		stored = append(stored, processedOffset{Topic: key.topic, Partition: key.partition, Offset: offset})
+	}
+	sort.Slice(stored, func(i, j int) bool {
+		if stored[i].Topic != stored[j].Topic {
+			return stored[i].Topic < stored[j].Topic
+		}
+		return stored[i].Partition < stored[j].Partition
+	})
+
+	data, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if dir := filepath.Dir(p.path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+
+	tmp := p.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, p.path)
+}
@@ -0,0 +1,61 @@
+package kafka
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+)
+
+func TestProcessedOffsetsSurviveRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "offsets", "processed_offsets.json")
+
+	before := newProcessedOffsets(path)
+	if err := before.Load(); err != nil {
+		t.Fatalf("Load without a file: %v", err)
+	}
+	for _, msg := range []kafka.Message{
+		{Topic: "weather", Partition: 0, Offset: 4},
+		{Topic: "weather", Partition: 1, Offset: 9},
+	} {
+		if err := before.Record(msg); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+
+	after := newProcessedOffsets(path)
+	if err := after.Load(); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		msg  kafka.Message
+		want bool
+	}{
+		{name: "processed offset", msg: kafka.Message{Topic: "weather", Partition: 0, Offset: 4}, want: true},
+		{name: "earlier offset", msg: kafka.Message{Topic: "weather", Partition: 1, Offset: 3}, want: true},
+		{name: "next offset", msg: kafka.Message{Topic: "weather", Partition: 0, Offset: 5}},
+		{name: "unknown partition", msg: kafka.Message{Topic: "weather", Partition: 2, Offset: 0}},
+		{name: "other topic", msg: kafka.Message{Topic: "weather_dead_letter", Partition: 0, Offset: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := after.Seen(tt.msg); got != tt.want {
+				t.Errorf("Seen(%s[%d]@%d) = %v, want %v", tt.msg.Topic, tt.msg.Partition, tt.msg.Offset, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessedOffsetsLoadRejectsCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "processed_offsets.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := newProcessedOffsets(path).Load(); err == nil {
+		t.Error("Load of a corrupt file succeeded")
+	}
+}
@@ -0,0 +1,123 @@
+package kafka
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+
+	"github.com/abhijeet1999/weather/Producer/utils"
+	"github.com/abhijeet1999/weather/codec"
+	"github.com/abhijeet1999/weather/models"
+	"github.com/segmentio/kafka-go"
+)
+
+// ErrUnsupportedSchema is returned for messages whose schema major version this consumer cannot read
+var ErrUnsupportedSchema = errors.New("unsupported message schema")
+
+// newerVersionsSeen records newer minor schema versions that have already been logged
+var newerVersionsSeen sync.Map
+
+// decodeMessage decodes a message written with any supported codec and schema version into the current schema.
+// Older versions are upgraded; newer minor versions decode with their added fields ignored.
+func decodeMessage(msg kafka.Message, registry *codec.Registry) (models.WeatherMessage, error) {
+	var weatherMsg models.WeatherMessage
+
+	// Messages from before codecs were advertised are JSON
+	messageCodec, err := codec.New(headerValue(msg, "codec"))
+	if err != nil {
+		return weatherMsg, fmt.Errorf("%w: %v", ErrUnsupportedSchema, err)
+	}
+
+	version := headerValue(msg, "schema_version")
+	if codec.RequiresSchema(messageCodec.Name()) {
+		// Binary payloads can only be read with the writer's schema, so the registry decides the version
+		schema, err := lookupSchema(msg, messageCodec.Name(), registry)
+		if err != nil {
+			return weatherMsg, err
+		}
+		version = schema.Version
+	} else if version == "" {
+		var envelope struct {
+			SchemaVersion string `json:"schema_version"`
+		}
+		if err := json.Unmarshal(msg.Value, &envelope); err != nil {
+			return weatherMsg, fmt.Errorf("failed to unmarshal message: %v", err)
+		}
+		version = envelope.SchemaVersion
+	}
+
+	// Messages from before versioning have the 1.0 layout
+	major, minor := 1, 0
+	if version != "" {
+		major, minor, err = models.ParseSchemaVersion(version)
+		if err != nil {
+			return weatherMsg, fmt.Errorf("%w: %v", ErrUnsupportedSchema, err)
+		}
+	}
+
+	if major != models.MessageSchemaMajor {
+		return weatherMsg, fmt.Errorf("%w: version %s (this consumer reads %d.x)", ErrUnsupportedSchema, version, models.MessageSchemaMajor)
+	}
+
+	if err := messageCodec.Unmarshal(msg.Value, &weatherMsg); err != nil {
+		return weatherMsg, fmt.Errorf("failed to unmarshal %s message: %v", messageCodec.Name(), err)
+	}
+
+	if version == "" {
+		upgradeLegacyMessage(&weatherMsg)
+	}
+	if minor > models.MessageSchemaMinor {
+		logNewerMinorOnce(version)
+	}
+
+	weatherMsg.SchemaVersion = models.MessageSchemaVersion
+	return weatherMsg, nil
+}
+
+// lookupSchema resolves the schema_id header of a binary message against the registry
+func lookupSchema(msg kafka.Message, codecName string, registry *codec.Registry) (codec.Schema, error) {
+	idStr := headerValue(msg, "schema_id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return codec.Schema{}, fmt.Errorf("%w: %s message has invalid schema_id '%s'", ErrUnsupportedSchema, codecName, idStr)
+	}
+
+	schema, exists := registry.Schema(id)
+	if !exists {
+		return codec.Schema{}, fmt.Errorf("%w: schema id %d is not in the schema registry", ErrUnsupportedSchema, id)
+	}
+	if schema.Codec != codecName {
+		return codec.Schema{}, fmt.Errorf("%w: schema id %d is a %s schema, but the message is %s", ErrUnsupportedSchema, id, schema.Codec, codecName)
+	}
+
+	return schema, nil
+}
+
+// upgradeLegacyMessage fills in fields that messages from before versioning did not carry
+func upgradeLegacyMessage(msg *models.WeatherMessage) {
+	// Unit selection was added after the original format, which was always metric
+	if msg.Units == "" {
+		msg.Units = utils.UnitMetric
+	}
+}
+
+// logNewerMinorOnce notes the first message of a newer minor schema version
+func logNewerMinorOnce(version string) {
+	if _, seen := newerVersionsSeen.LoadOrStore(version, true); !seen {
+		log.Printf("ℹ️ Received messages with newer schema version %s; fields added after %s are ignored",
+			version, models.MessageSchemaVersion)
+	}
+}
+
+// headerValue returns the value of a message header, or "" if it is not set
+func headerValue(msg kafka.Message, key string) string {
+	for _, header := range msg.Headers {
+		if header.Key == key {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
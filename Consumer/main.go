@@ -1,58 +1,130 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
 	"os/signal"
+	"strings"
+	"sync"
 	"syscall"
+	"time"
 
 	"github.com/abhijeet1999/weather/Consumer/alerts"
 	"github.com/abhijeet1999/weather/Consumer/api"
 	"github.com/abhijeet1999/weather/Consumer/kafka"
 	"github.com/abhijeet1999/weather/Producer/utils"
+	"github.com/abhijeet1999/weather/codec"
+	"github.com/abhijeet1999/weather/kafkaconfig"
+	"github.com/abhijeet1999/weather/models"
 )
 
 func main() {
 	// Configuration from environment variables
-	kafkaServers := getEnvOrDefault("KAFKA_SERVERS", "kafka:9092")
-	kafkaTopic := getEnvOrDefault("KAFKA_TOPIC", "weather_data")
+	kafkaConfig := kafkaconfig.FromEnv("kafka:9092", "weather-consumer")
+	kafkaTopics := kafkaconfig.TopicsFromEnv("weather_data")
 	consumerGroupID := getEnvOrDefault("CONSUMER_GROUP_ID", "weather-consumer-group")
+	deadLetterTopic := getEnvOrDefault("KAFKA_DEAD_LETTER_TOPIC", kafkaTopics.Default+"_dead_letter")
+	commitInterval := getDurationEnvOrDefault("KAFKA_COMMIT_INTERVAL", 5*time.Second)
+	offsetsFile := getEnvOrDefault("PROCESSED_OFFSETS_FILE", "processed_offsets.json")
+	dedupWindow := getDurationEnvOrDefault("DEDUP_WINDOW", 48*time.Hour)
+	schemaRegistryFile := getEnvOrDefault("SCHEMA_REGISTRY_FILE", "schemas/registry.yaml")
 	metricsPort := getEnvOrDefault("METRICS_PORT", "8080")
 	apiPort := getEnvOrDefault("API_PORT", "8081")
+	inputFile := getEnvOrDefault("INPUT_FILE", "input.txt")
+	reloadInterval := getDurationEnvOrDefault("INPUT_RELOAD_INTERVAL", 15*time.Second)
+	shutdownTimeout := getDurationEnvOrDefault("SHUTDOWN_TIMEOUT", 15*time.Second)
 
 	log.Println("🚀 Starting Weather Consumer...")
-	log.Printf("📥 Kafka Servers: %s", kafkaServers)
-	log.Printf("📥 Kafka Topic: %s", kafkaTopic)
+	log.Printf("📥 Kafka Servers: %s", strings.Join(kafkaConfig.Brokers, ","))
+	log.Printf("📥 Kafka Topics: %s", kafkaTopics)
 	log.Printf("📥 Consumer Group: %s", consumerGroupID)
 	log.Printf("📊 Metrics Port: %s", metricsPort)
 	log.Printf("🌐 API Port: %s", apiPort)
 
+	// Root context cancelled on shutdown
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
 	// Initialize alert evaluator with input.txt data
-	alertEvaluator := initializeAlertEvaluator()
+	alertEvaluator := initializeAlertEvaluator(inputFile)
+
+	// Replace alert rules when the input file changes or on SIGHUP
+	watcher := utils.NewLocationsWatcher(inputFile, reloadInterval, func(requests []models.WeatherRequest) error {
+		alertEvaluator.ReplaceAlertRules(buildAlertRules(requests))
+		return nil
+	})
+	go watcher.Run(ctx)
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			log.Println("🔄 Received SIGHUP, reloading input file...")
+			watcher.Reload()
+		}
+	}()
+
+	// Schema registry for Protobuf and Avro messages; JSON messages are readable without it
+	schemaRegistry, err := codec.LoadRegistry(schemaRegistryFile)
+	if err != nil {
+		log.Printf("⚠️ %v; only JSON messages can be decoded", err)
+	}
 
 	// Initialize Kafka consumer
-	consumer, err := kafka.NewKafkaConsumer(kafkaServers, kafkaTopic, consumerGroupID, alertEvaluator)
+	kafkaConn, err := kafkaconfig.NewConnection(kafkaConfig)
+	if err != nil {
+		log.Fatalf("❌ Invalid Kafka connection settings: %v", err)
+	}
+	messageTypes, err := kafkaconfig.ParseMessageTypes(os.Getenv("CONSUMER_MESSAGE_TYPES"))
+	if err != nil {
+		log.Fatalf("❌ Invalid CONSUMER_MESSAGE_TYPES: %v", err)
+	}
+	consumer, err := kafka.NewKafkaConsumer(kafka.Config{
+		Connection:      kafkaConn,
+		Topics:          kafkaTopics,
+		MessageTypes:    messageTypes,
+		GroupID:         consumerGroupID,
+		DeadLetterTopic: deadLetterTopic,
+		CommitInterval:  commitInterval,
+		OffsetsFile:     offsetsFile,
+		DedupWindow:     dedupWindow,
+	}, schemaRegistry, alertEvaluator)
 	if err != nil {
 		log.Fatalf("❌ Failed to create Kafka consumer: %v", err)
 	}
 	defer consumer.Close()
 
+	// Components stopped and drained on shutdown
+	var wg sync.WaitGroup
+
 	// Start Prometheus metrics server
 	metrics := consumer.GetMetrics()
-	metrics.StartMetricsServer(metricsPort)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := metrics.StartMetricsServer(ctx, metricsPort, shutdownTimeout); err != nil {
+			log.Printf("❌ %v", err)
+		}
+	}()
 
 	// Initialize HTTP API
 	weatherAPI := api.NewWeatherAPI(consumer)
 
 	// Start Kafka consumer in background
+	wg.Add(1)
 	go func() {
-		log.Println("🔄 Starting Kafka consumer...")
-		consumer.StartConsuming()
+		defer wg.Done()
+		consumer.StartConsuming(ctx)
 	}()
 
 	// Start HTTP API server
+	wg.Add(1)
 	go func() {
-		weatherAPI.StartServer(apiPort)
+		defer wg.Done()
+		if err := weatherAPI.StartServer(ctx, apiPort, shutdownTimeout); err != nil {
+			log.Printf("❌ %v", err)
+		}
 	}()
 
 	log.Println("✅ Weather Consumer started successfully!")
@@ -66,8 +138,22 @@ func main() {
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
 	<-c
 
+	// Stop fetching, finish the in-flight message, commit offsets and shut down the HTTP servers
 	log.Println("🛑 Shutting down Weather Consumer...")
-	log.Println("✅ Shutdown complete")
+	cancel()
+
+	stopped := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		log.Println("✅ Shutdown complete")
+	case <-time.After(shutdownTimeout):
+		log.Printf("⚠️ Shutdown did not complete within %s", shutdownTimeout)
+	}
 }
 
 // getEnvOrDefault returns environment variable value or default
@@ -78,15 +164,30 @@ func getEnvOrDefault(key, defaultValue string) string {
 	return defaultValue
 }
 
-// initializeAlertEvaluator initializes the alert evaluator with data from input.txt
-func initializeAlertEvaluator() *alerts.AlertEvaluator {
+// getDurationEnvOrDefault returns environment variable parsed as a duration or default
+func getDurationEnvOrDefault(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		log.Printf("⚠️ Invalid %s value '%s', using default %s", key, value, defaultValue)
+		return defaultValue
+	}
+
+	return duration
+}
+
+// initializeAlertEvaluator initializes the alert evaluator with data from the input file
+func initializeAlertEvaluator(inputFile string) *alerts.AlertEvaluator {
 	log.Println("📋 Initializing Alert Evaluator...")
 
 	alertEvaluator := alerts.NewAlertEvaluator()
 
-	// Parse input.txt to get alert rules
-	inputFile := getEnvOrDefault("INPUT_FILE", "input.txt")
-	requests, err := utils.ParseInputFile(inputFile)
+	// Load locations (input.txt or YAML/JSON config) to get alert rules
+	requests, err := utils.LoadLocations(inputFile)
 	if err != nil {
 		log.Printf("❌ Error parsing input file for alerts: %v", err)
 		log.Printf("⚠️ Continuing with empty alert rules - no alerts will be triggered")
@@ -94,28 +195,19 @@ func initializeAlertEvaluator() *alerts.AlertEvaluator {
 	}
 
 	// Add alert rules for each location
-	validRules := 0
-	for _, req := range requests {
-		// Get city name from zip code (simplified mapping)
-		cityName := getCityNameFromZipCode(req.ZipCode)
-		alertEvaluator.AddAlertRule(req.ZipCode, cityName, req.AlertTemp, req.AlertWind, req.AlertHumidity)
-		validRules++
-	}
+	rules := buildAlertRules(requests)
+	alertEvaluator.ReplaceAlertRules(rules)
 
-	log.Printf("✅ Alert Evaluator initialized with %d valid rules", validRules)
+	log.Printf("✅ Alert Evaluator initialized with %d valid rules", len(rules))
 	return alertEvaluator
 }
 
-// getCityNameFromZipCode returns city name for a given zip code
-func getCityNameFromZipCode(zipCode string) string {
-	cityMap := map[string]string{
-		"12601": "Poughkeepsie",
-		"10001": "New York City",
-		"90210": "Beverly Hills",
-	}
-
-	if city, exists := cityMap[zipCode]; exists {
-		return city
+// buildAlertRules creates an alert rule for each location
+func buildAlertRules(requests []models.WeatherRequest) []alerts.AlertRule {
+	rules := make([]alerts.AlertRule, 0, len(requests))
+	for _, req := range requests {
+		// Locations without a configured name learn it from incoming messages
+		rules = append(rules, alerts.NewAlertRule(req.LocationID(), req.Name, req.AlertTemp, req.AlertWind, req.AlertHumidity))
 	}
-	return "Unknown City"
+	return rules
 }
@@ -1,8 +1,12 @@
 package prometheus
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -23,6 +27,11 @@ type WeatherMetrics struct {
 	forecastWindSpeed   *prometheus.GaugeVec
 	forecastPressure    *prometheus.GaugeVec
 
+	// Daily forecast summary metrics
+	dailyTemperature *prometheus.GaugeVec
+	dailyHumidity    *prometheus.GaugeVec
+	dailyWindSpeed   *prometheus.GaugeVec
+
 	// Counter metrics
 	weatherRequestsTotal *prometheus.CounterVec
 	weatherErrorsTotal   *prometheus.CounterVec
@@ -104,6 +113,31 @@ func NewWeatherMetrics() *WeatherMetrics {
 			[]string{"city", "zip_code", "forecast_time"},
 		),
 
+		// Daily forecast summary gauges, by day offset (1 is today at the location)
+		dailyTemperature: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "weather_daily_temperature_celsius",
+				Help: "Daily forecast temperature in Celsius by stat (min/avg/max)",
+			},
+			[]string{"city", "zip_code", "day", "stat"},
+		),
+
+		dailyHumidity: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "weather_daily_humidity_percent",
+				Help: "Daily forecast average humidity percentage",
+			},
+			[]string{"city", "zip_code", "day"},
+		),
+
+		dailyWindSpeed: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "weather_daily_wind_speed_mps",
+				Help: "Daily forecast average wind speed in meters per second",
+			},
+			[]string{"city", "zip_code", "day"},
+		),
+
 		// Counter metrics
 		weatherRequestsTotal: prometheus.NewCounterVec(
 			prometheus.CounterOpts{
@@ -159,6 +193,9 @@ func NewWeatherMetrics() *WeatherMetrics {
 		metrics.forecastHumidity,
 		metrics.forecastWindSpeed,
 		metrics.forecastPressure,
+		metrics.dailyTemperature,
+		metrics.dailyHumidity,
+		metrics.dailyWindSpeed,
 		metrics.weatherRequestsTotal,
 		metrics.weatherErrorsTotal,
 		metrics.weatherProcessingTime,
@@ -190,6 +227,18 @@ func (wm *WeatherMetrics) UpdateForecastWeatherMetrics(city string, temp, humidi
 	wm.forecastPressure.WithLabelValues(city, zipCode, forecastTime).Set(float64(pressure))
 }
 
+// UpdateDailyWeatherMetrics updates the daily forecast summary metrics of one day offset;
+// daily summaries never touch the current weather gauges
+func (wm *WeatherMetrics) UpdateDailyWeatherMetrics(city, zipCode string, day int, tempMin, tempAvg, tempMax, humidity, windSpeed float32) {
+	dayLabel := strconv.Itoa(day)
+
+	wm.dailyTemperature.WithLabelValues(city, zipCode, dayLabel, "min").Set(float64(tempMin))
+	wm.dailyTemperature.WithLabelValues(city, zipCode, dayLabel, "avg").Set(float64(tempAvg))
+	wm.dailyTemperature.WithLabelValues(city, zipCode, dayLabel, "max").Set(float64(tempMax))
+	wm.dailyHumidity.WithLabelValues(city, zipCode, dayLabel).Set(float64(humidity))
+	wm.dailyWindSpeed.WithLabelValues(city, zipCode, dayLabel).Set(float64(windSpeed))
+}
+
 // IncrementWeatherRequests increments the weather requests counter
 func (wm *WeatherMetrics) IncrementWeatherRequests(city, zipCode, status string) {
 	wm.weatherRequestsTotal.WithLabelValues(city, zipCode, status).Inc()
@@ -226,14 +275,34 @@ func (wm *WeatherMetrics) UpdateAlertMetrics(city, alertType, severity string, v
 		severity, alertType, city, value, threshold)
 }
 
-// StartMetricsServer starts the Prometheus metrics HTTP server
-func (wm *WeatherMetrics) StartMetricsServer(port string) {
-	http.Handle("/metrics", promhttp.Handler())
+// StartMetricsServer runs the Prometheus metrics HTTP server until ctx is cancelled, then shuts it down,
+// giving in-flight scrapes up to shutdownTimeout to complete
+func (wm *WeatherMetrics) StartMetricsServer(ctx context.Context, port string, shutdownTimeout time.Duration) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+
+	server := &http.Server{
+		Addr:    ":" + port,
+		Handler: mux,
+	}
 
+	shutdownDone := make(chan error, 1)
 	go func() {
-		log.Printf("📊 Starting Prometheus metrics server on port %s", port)
-		if err := http.ListenAndServe(":"+port, nil); err != nil {
-			log.Printf("❌ Error starting metrics server: %v", err)
-		}
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		shutdownDone <- server.Shutdown(shutdownCtx)
 	}()
+
+	log.Printf("📊 Starting Prometheus metrics server on port %s", port)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to start metrics server: %v", err)
+	}
+
+	if err := <-shutdownDone; err != nil {
+		return fmt.Errorf("metrics server did not shut down cleanly: %v", err)
+	}
+	log.Println("📊 Prometheus metrics server stopped")
+	return nil
 }
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	"github.com/abhijeet1999/weather/Producer/weather/fakeowm"
+)
+
+func main() {
+	addr := flag.String("addr", ":8090", "address to listen on")
+	fixturesDir := flag.String("fixtures", "", "directory of recorded fixtures (default: bundled fixtures)")
+	apiKey := flag.String("api-key", "", "API key required in the appid parameter (default: any)")
+	recordedTimes := flag.Bool("recorded-times", false, "serve fixture timestamps unchanged instead of shifting them to now")
+	flag.Parse()
+
+	var opts []fakeowm.Option
+	if *fixturesDir != "" {
+		opts = append(opts, fakeowm.WithFixturesDir(*fixturesDir))
+	}
+	if *apiKey != "" {
+		opts = append(opts, fakeowm.WithAPIKey(*apiKey))
+	}
+	if *recordedTimes {
+		opts = append(opts, fakeowm.WithRecordedTimes())
+	}
+
+	log.Printf("🧪 Fake OpenWeatherMap server listening on %s", *addr)
+	log.Printf("🧪 Point the producer at it with WEATHER_API_BASE_URL=http://localhost%s", *addr)
+
+	if err := http.ListenAndServe(*addr, fakeowm.New(opts...)); err != nil {
+		log.Fatalf("❌ Failed to start fake server: %v", err)
+	}
+}
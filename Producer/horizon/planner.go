@@ -0,0 +1,208 @@
+package horizon
+
+import (
+	"strings"
+	"time"
+
+	"github.com/abhijeet1999/weather/models"
+)
+
+// Default split between hourly and daily forecast messages
+const (
+	DefaultHourlyHorizon = 48 * time.Hour // 16 items of the 3-hour OpenWeatherMap forecast
+	DefaultExtendedDays  = 4
+)
+
+// dateLayout formats the calendar date of a forecast day
+const dateLayout = "2006-01-02"
+
+// Config holds the split between hourly and daily forecast messages
+type Config struct {
+	HourlyHorizon time.Duration // How far past the first forecast slot extended requests get hourly messages
+	ExtendedDays  int           // Requests for at least this many days get hourly and daily messages instead of one forecast message
+}
+
+// Planner decides which forecast messages to publish for the number of days a location requests.
+// Day 1 is today at the location; a request for N days covers the calendar days up to and including today + N-1.
+// Days follow the UTC offset the provider reports with the forecast, not the zone the producer runs in.
+type Planner struct {
+	config Config
+	now    func() time.Time
+}
+
+// Plan lists the forecast messages to publish for one request
+type Plan struct {
+	Extended bool                               // Publish hourly and daily messages instead of the forecast message
+	Forecast models.OpenWeatherForecastResponse // Forecast trimmed to the requested days
+	Hourly   int                                // Number of leading Forecast.List items published as hourly messages
+	Days     []Day                              // Every requested day, in order
+}
+
+// Day holds the forecast slots of one requested calendar day
+type Day struct {
+	Number int    // 1 for today
+	Date   string // YYYY-MM-DD
+	Items  []models.ForecastItem
+}
+
+// NewPlanner creates a new Planner instance; zero config values fall back to the defaults
+func NewPlanner(config Config) *Planner {
+	if config.HourlyHorizon <= 0 {
+		config.HourlyHorizon = DefaultHourlyHorizon
+	}
+	if config.ExtendedDays <= 0 {
+		config.ExtendedDays = DefaultExtendedDays
+	}
+	return &Planner{
+		config: config,
+		now:    time.Now,
+	}
+}
+
+// Plan trims a forecast to the requested number of days and splits it into the messages to publish
+func (p *Planner) Plan(forecast models.OpenWeatherForecastResponse, days int) Plan {
+	plan := Plan{
+		Extended: days >= p.config.ExtendedDays,
+		Forecast: forecast,
+	}
+	if days <= 0 {
+		plan.Forecast.List = nil
+		plan.Forecast.Cnt = 0
+		return plan
+	}
+
+	location := forecastZone(forecast)
+	today := p.now().In(location)
+	plan.Days = make([]Day, days)
+	dayNumbers := make(map[string]int, days)
+	for i := range plan.Days {
+		date := today.AddDate(0, 0, i).Format(dateLayout)
+		plan.Days[i] = Day{Number: i + 1, Date: date}
+		dayNumbers[date] = i + 1
+	}
+
+	plan.Forecast.List = make([]models.ForecastItem, 0, len(forecast.List))
+	for _, item := range forecast.List {
+		number, requested := dayNumbers[time.Unix(item.Dt, 0).In(location).Format(dateLayout)]
+		if !requested {
+			continue
+		}
+		plan.Forecast.List = append(plan.Forecast.List, item)
+		plan.Days[number-1].Items = append(plan.Days[number-1].Items, item)
+	}
+	plan.Forecast.Cnt = len(plan.Forecast.List)
+
+	if plan.Extended && len(plan.Forecast.List) > 0 {
+		hourlyEnd := time.Unix(plan.Forecast.List[0].Dt, 0).Add(p.config.HourlyHorizon)
+		for _, item := range plan.Forecast.List {
+			if !time.Unix(item.Dt, 0).Before(hourlyEnd) {
+				break
+			}
+			plan.Hourly++
+		}
+	}
+
+	return plan
+}
+
+// forecastZone returns the fixed zone of the UTC offset reported with a forecast.
+// Providers report the current offset only, so days after a daylight saving change inside the horizon are off by an hour.
+func forecastZone(forecast models.OpenWeatherForecastResponse) *time.Location {
+	return time.FixedZone(models.TimezoneName(forecast.City.Timezone), forecast.City.Timezone)
+}
+
+// Summary calculates the daily weather summary from the day's forecast slots, or returns nil if it has none
+func (d Day) Summary() *models.DailyWeatherData {
+	if len(d.Items) == 0 {
+		return nil
+	}
+
+	first := d.Items[0]
+	summary := &models.DailyWeatherData{
+		Day:          d.Number,
+		Date:         d.Date,
+		TempMin:      first.Main.TempMin,
+		TempMax:      first.Main.TempMax,
+		FeelsLikeMin: first.Main.FeelsLike,
+		FeelsLikeMax: first.Main.FeelsLike,
+	}
+
+	// Calculate summary statistics
+	var tempSum, windSum float32
+	var humiditySum, cloudsSum int
+
+	for _, item := range d.Items {
+		summary.TempMin = min(summary.TempMin, item.Main.TempMin)
+		summary.TempMax = max(summary.TempMax, item.Main.TempMax)
+		summary.FeelsLikeMin = min(summary.FeelsLikeMin, item.Main.FeelsLike)
+		summary.FeelsLikeMax = max(summary.FeelsLikeMax, item.Main.FeelsLike)
+		summary.WindGust = max(summary.WindGust, item.Wind.Gust, item.Wind.Speed) // Gusts are optional and never below the wind speed
+		summary.Pop = max(summary.Pop, item.Pop)
+		if item.Rain != nil {
+			summary.Rain += item.Rain.ThreeHours
+		}
+		if item.Snow != nil {
+			summary.Snow += item.Snow.ThreeHours
+		}
+		tempSum += item.Main.Temp
+		humiditySum += item.Main.Humidity
+		windSum += item.Wind.Speed
+		cloudsSum += item.Clouds.All
+	}
+
+	count := len(d.Items)
+	summary.TempAvg = tempSum / float32(count)
+	summary.Humidity = humiditySum / count
+	summary.WindSpeed = windSum / float32(count)
+	summary.Clouds = cloudsSum / count
+
+	if condition, found := d.dominantCondition(); found {
+		summary.Description = condition.Description
+		summary.Icon = condition.Icon
+	}
+	return summary
+}
+
+// dominantCondition returns the weather condition that lasts longest during the day, preferring its daytime icon.
+// Each slot lasts until the next one; the last slot lasts as long as the one before it.
+func (d Day) dominantCondition() (models.OpenWeatherCondition, bool) {
+	durations := make(map[int]int64)
+	representative := make(map[int]models.OpenWeatherCondition)
+	var order []int
+
+	for i, item := range d.Items {
+		if len(item.Weather) == 0 {
+			continue
+		}
+
+		duration := int64(1)
+		switch {
+		case i+1 < len(d.Items):
+			duration = d.Items[i+1].Dt - item.Dt
+		case i > 0:
+			duration = item.Dt - d.Items[i-1].Dt
+		}
+
+		condition := item.Weather[0]
+		if _, seen := representative[condition.Id]; !seen {
+			order = append(order, condition.Id)
+			representative[condition.Id] = condition
+		} else if item.Sys.Pod == "d" && !strings.HasSuffix(representative[condition.Id].Icon, "d") {
+			representative[condition.Id] = condition
+		}
+		durations[condition.Id] += duration
+	}
+
+	if len(order) == 0 {
+		return models.OpenWeatherCondition{}, false
+	}
+
+	// Ties go to the condition seen first
+	dominant := order[0]
+	for _, id := range order[1:] {
+		if durations[id] > durations[dominant] {
+			dominant = id
+		}
+	}
+	return representative[dominant], true
+}
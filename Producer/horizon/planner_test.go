@@ -0,0 +1,212 @@
+package horizon
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/abhijeet1999/weather/models"
+)
+
+// testForecast returns count 3-hourly forecast slots starting at start, reported with the UTC offset of start
+func testForecast(start time.Time, count int) models.OpenWeatherForecastResponse {
+	var forecast models.OpenWeatherForecastResponse
+	_, forecast.City.Timezone = start.Zone()
+	for i := 0; i < count; i++ {
+		var item models.ForecastItem
+		item.Dt = start.Add(time.Duration(i) * 3 * time.Hour).Unix()
+		forecast.List = append(forecast.List, item)
+	}
+	forecast.Cnt = count
+	return forecast
+}
+
+func TestPlan(t *testing.T) {
+	zone := time.FixedZone("UTC-07:00", -7*3600)
+	now := time.Date(2024, 6, 1, 11, 30, 0, 0, zone)
+	// Five days of slots from today 12:00: 4 today, 8 on each of the next four days and 4 on the sixth
+	forecast := testForecast(time.Date(2024, 6, 1, 12, 0, 0, 0, zone), 40)
+
+	tests := []struct {
+		name         string
+		days         int
+		wantExtended bool
+		wantItems    int
+		wantHourly   int
+		wantDayItems []int
+	}{
+		{name: "no days", days: 0},
+		{name: "today", days: 1, wantItems: 4, wantDayItems: []int{4}},
+		{name: "three days", days: 3, wantItems: 20, wantDayItems: []int{4, 8, 8}},
+		{name: "extended request", days: 4, wantExtended: true, wantItems: 28, wantHourly: 16, wantDayItems: []int{4, 8, 8, 8}},
+		{name: "five days", days: 5, wantExtended: true, wantItems: 36, wantHourly: 16, wantDayItems: []int{4, 8, 8, 8, 8}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewPlanner(Config{})
+			p.now = func() time.Time { return now.UTC() }
+			plan := p.Plan(forecast, tt.days)
+
+			if plan.Extended != tt.wantExtended {
+				t.Errorf("Extended = %v, want %v", plan.Extended, tt.wantExtended)
+			}
+			if len(plan.Forecast.List) != tt.wantItems || plan.Forecast.Cnt != tt.wantItems {
+				t.Errorf("forecast items, cnt = %d, %d, want %d", len(plan.Forecast.List), plan.Forecast.Cnt, tt.wantItems)
+			}
+			if plan.Hourly != tt.wantHourly {
+				t.Errorf("Hourly = %d, want %d", plan.Hourly, tt.wantHourly)
+			}
+
+			var dayItems []int
+			for i, day := range plan.Days {
+				dayItems = append(dayItems, len(day.Items))
+				if want := now.AddDate(0, 0, i).Format(dateLayout); day.Number != i+1 || day.Date != want {
+					t.Errorf("day %d = %d %s, want %d %s", i, day.Number, day.Date, i+1, want)
+				}
+				for _, item := range day.Items {
+					if date := time.Unix(item.Dt, 0).In(zone).Format(dateLayout); date != day.Date {
+						t.Errorf("day %d holds a slot of %s", day.Number, date)
+					}
+				}
+			}
+			if !reflect.DeepEqual(dayItems, tt.wantDayItems) {
+				t.Errorf("items per day = %v, want %v", dayItems, tt.wantDayItems)
+			}
+		})
+	}
+}
+
+func TestPlanUsesForecastOffset(t *testing.T) {
+	now := time.Date(2024, 6, 1, 18, 0, 0, 0, time.UTC)
+	slot := time.Date(2024, 6, 2, 3, 0, 0, 0, time.UTC) // Evening of June 1 west of Greenwich
+
+	tests := []struct {
+		name      string
+		utcOffset int
+		wantDate  string
+		wantItems int
+	}{
+		{name: "UTC", utcOffset: 0, wantDate: "2024-06-01"},
+		{name: "west of UTC", utcOffset: -7 * 3600, wantDate: "2024-06-01", wantItems: 1},
+		{name: "east of UTC", utcOffset: 9 * 3600, wantDate: "2024-06-02", wantItems: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forecast := testForecast(slot, 1)
+			forecast.City.Timezone = tt.utcOffset
+			p := NewPlanner(Config{})
+			p.now = func() time.Time { return now }
+
+			plan := p.Plan(forecast, 1)
+			if plan.Days[0].Date != tt.wantDate || len(plan.Days[0].Items) != tt.wantItems {
+				t.Errorf("day 1 = %s with %d slots, want %s with %d", plan.Days[0].Date, len(plan.Days[0].Items), tt.wantDate, tt.wantItems)
+			}
+		})
+	}
+}
+
+func TestPlanHourlyHorizon(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	forecast := testForecast(now, 40)
+
+	tests := []struct {
+		horizon time.Duration
+		want    int
+	}{
+		{horizon: 3 * time.Hour, want: 1},
+		{horizon: 24 * time.Hour, want: 8},
+		{horizon: 200 * time.Hour, want: 40},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.horizon.String(), func(t *testing.T) {
+			p := NewPlanner(Config{HourlyHorizon: tt.horizon, ExtendedDays: 1})
+			p.now = func() time.Time { return now }
+
+			if got := p.Plan(forecast, 5).Hourly; got != tt.want {
+				t.Errorf("Hourly = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDaySummary(t *testing.T) {
+	start := time.Date(2024, 6, 2, 0, 0, 0, 0, time.UTC)
+	// slot returns a forecast slot hour hours into the day with one condition, adjusted by set
+	slot := func(hour int, id int, description, pod string, set func(item *models.ForecastItem)) models.ForecastItem {
+		var item models.ForecastItem
+		item.Dt = start.Add(time.Duration(hour) * time.Hour).Unix()
+		item.Weather = []models.OpenWeatherCondition{{Id: id, Description: description, Icon: "10" + pod}}
+		item.Sys.Pod = pod
+		if set != nil {
+			set(&item)
+		}
+		return item
+	}
+
+	tests := []struct {
+		name string
+		day  Day
+		want *models.DailyWeatherData
+	}{
+		{name: "no slots", day: Day{Number: 1, Date: "2024-06-01"}},
+		{
+			name: "statistics",
+			day: Day{Number: 2, Date: "2024-06-02", Items: []models.ForecastItem{
+				slot(0, 500, "light rain", "n", func(item *models.ForecastItem) {
+					item.Main.Temp, item.Main.TempMin, item.Main.TempMax, item.Main.FeelsLike = 14, 12, 15, 13
+					item.Main.Humidity, item.Clouds.All = 80, 100
+					item.Wind.Speed, item.Wind.Gust = 2, 5
+					item.Pop = 0.8
+					item.Rain = &models.Precipitation{ThreeHours: 1.5}
+				}),
+				slot(3, 500, "light rain", "d", func(item *models.ForecastItem) {
+					item.Main.Temp, item.Main.TempMin, item.Main.TempMax, item.Main.FeelsLike = 20, 18, 22, 21
+					item.Main.Humidity, item.Clouds.All = 60, 50
+					item.Wind.Speed = 6 // No gust reported
+					item.Pop = 0.4
+					item.Rain = &models.Precipitation{ThreeHours: 0.5}
+					item.Snow = &models.Precipitation{ThreeHours: 0.25}
+				}),
+			}},
+			want: &models.DailyWeatherData{
+				Day: 2, Date: "2024-06-02", TempMin: 12, TempMax: 22, TempAvg: 17, Humidity: 70, WindSpeed: 4,
+				Description: "light rain", Icon: "10d",
+				FeelsLikeMin: 13, FeelsLikeMax: 21, WindGust: 6, Clouds: 75, Pop: 0.8, Rain: 2, Snow: 0.25,
+			},
+		},
+		{
+			name: "longest condition wins",
+			day: Day{Number: 1, Date: "2024-06-02", Items: []models.ForecastItem{
+				slot(0, 800, "clear sky", "n", nil),
+				slot(3, 500, "light rain", "d", nil),
+				slot(15, 800, "clear sky", "d", nil),
+				slot(18, 800, "clear sky", "n", nil),
+			}},
+			want: &models.DailyWeatherData{Day: 1, Date: "2024-06-02", Description: "light rain", Icon: "10d"},
+		},
+		{
+			name: "daytime icon of the condition",
+			day: Day{Number: 1, Date: "2024-06-02", Items: []models.ForecastItem{
+				slot(0, 800, "clear sky", "n", nil),
+				slot(3, 800, "clear sky", "d", nil),
+			}},
+			want: &models.DailyWeatherData{Day: 1, Date: "2024-06-02", Description: "clear sky", Icon: "10d"},
+		},
+		{
+			name: "slot without conditions",
+			day:  Day{Number: 1, Date: "2024-06-01", Items: []models.ForecastItem{{}}},
+			want: &models.DailyWeatherData{Day: 1, Date: "2024-06-01"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.day.Summary(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Summary() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
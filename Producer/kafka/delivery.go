@@ -0,0 +1,85 @@
+package kafka
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// DeliveryFunc is called once per message when Kafka acknowledges it (err is nil) or the writer gives up on it
+type DeliveryFunc func(messageType string, err error)
+
+// deliveryStats counts message deliveries since startup
+type deliveryStats struct {
+	Delivered uint64
+	Failed    uint64
+	Pending   int // Messages handed to the writer and not yet acknowledged or failed
+}
+
+// deliveryTracker counts messages in flight so Flush can wait for their acknowledgements
+type deliveryTracker struct {
+	mu      sync.Mutex
+	pending int
+	idle    chan struct{} // Closed while nothing is pending
+
+	delivered atomic.Uint64
+	failed    atomic.Uint64
+}
+
+// newDeliveryTracker creates a new deliveryTracker instance
+func newDeliveryTracker() *deliveryTracker {
+	idle := make(chan struct{})
+	close(idle)
+	return &deliveryTracker{idle: idle}
+}
+
+// add records n messages handed to the writer
+func (t *deliveryTracker) add(n int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.pending == 0 {
+		t.idle = make(chan struct{})
+	}
+	t.pending += n
+}
+
+// done records the outcome of n messages
+func (t *deliveryTracker) done(n int, err error) {
+	if err != nil {
+		t.failed.Add(uint64(n))
+	} else {
+		t.delivered.Add(uint64(n))
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.pending == 0 {
+		return // Already idle; the idle channel is closed
+	}
+	t.pending -= n
+	if t.pending <= 0 {
+		t.pending = 0
+		close(t.idle)
+	}
+}
+
+// waitIdle returns a channel that is closed once nothing is pending
+func (t *deliveryTracker) waitIdle() <-chan struct{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.idle
+}
+
+// stats returns the delivery counts
+func (t *deliveryTracker) stats() deliveryStats {
+	t.mu.Lock()
+	pending := t.pending
+	t.mu.Unlock()
+
+	return deliveryStats{
+		Delivered: t.delivered.Load(),
+		Failed:    t.failed.Load(),
+		Pending:   pending,
+	}
+}
@@ -0,0 +1,180 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// lastSeenSaveInterval is how often a changed last-seen table is written to disk
+const lastSeenSaveInterval = 5 * time.Second
+
+// LastSeen records the ID of the latest delivered message for each location and message slot (e.g. "10001|current"
+// or "10001|daily|2"), so observations that have not changed since the last poll are not published again.
+// The table is kept in memory and saved to an on-disk JSON file periodically and on Close, so it survives restarts.
+type LastSeen struct {
+	mu        sync.Mutex
+	path      string
+	retention time.Duration
+	entries   map[string]lastSeenEntry // Slot key to the latest delivered message
+	dirty     bool                     // Changed since the last save
+	now       func() time.Time
+}
+
+// lastSeenEntry is a delivered message, also used as the on-disk record format
+type lastSeenEntry struct {
+	Key         string    `json:"key"`
+	MessageID   string    `json:"message_id"`
+	DeliveredAt time.Time `json:"delivered_at"`
+}
+
+// NewLastSeen creates a new LastSeen instance.
+// Slots not delivered to for longer than retention are dropped, e.g. those of removed locations; an empty path disables persistence.
+func NewLastSeen(path string, retention time.Duration) *LastSeen {
+	return &LastSeen{
+		path:      path,
+		retention: retention,
+		entries:   make(map[string]lastSeenEntry),
+		now:       time.Now,
+	}
+}
+
+// Load restores the table from the on-disk store, skipping expired entries
+func (t *LastSeen) Load() error {
+	if t.path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(t.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read last-seen table: %w", err)
+	}
+
+	var stored []lastSeenEntry
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return fmt.Errorf("failed to parse last-seen table %s: %w", t.path, err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, entry := range stored {
+		if entry.Key != "" && !t.expired(entry) {
+			t.entries[entry.Key] = entry
+		}
+	}
+
+	log.Printf("🗂️ Loaded %d last-seen message IDs from %s", len(t.entries), t.path)
+	return nil
+}
+
+// Seen reports whether messageID is the latest message delivered for a slot
+func (t *LastSeen) Seen(key, messageID string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry, exists := t.entries[key]
+	return exists && entry.MessageID == messageID && !t.expired(entry)
+}
+
+// Record marks messageID as the latest message delivered for a slot; the table is saved by Run or Close
+func (t *LastSeen) Record(key, messageID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.entries[key] = lastSeenEntry{Key: key, MessageID: messageID, DeliveredAt: t.now()}
+	t.dirty = true
+}
+
+// Len returns the number of slots in the table
+func (t *LastSeen) Len() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.entries)
+}
+
+// Run saves the table every lastSeenSaveInterval while it has changes, until ctx is cancelled
+func (t *LastSeen) Run(ctx context.Context) {
+	ticker := time.NewTicker(lastSeenSaveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			t.flush()
+		}
+	}
+}
+
+// Close saves any changes not yet written to disk
+func (t *LastSeen) Close() {
+	t.flush()
+}
+
+// flush drops expired entries and saves the table if it has changed
+func (t *LastSeen) flush() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.dirty {
+		return
+	}
+	for key, entry := range t.entries {
+		if t.expired(entry) {
+			delete(t.entries, key)
+		}
+	}
+
+	if err := t.save(); err != nil {
+		log.Printf("⚠️ Failed to persist last-seen table, will retry: %v", err)
+		return
+	}
+	t.dirty = false
+}
+
+// expired reports whether an entry is older than the retention; callers must hold t.mu
+func (t *LastSeen) expired(entry lastSeenEntry) bool {
+	return t.retention > 0 && t.now().Sub(entry.DeliveredAt) > t.retention
+}
+
+// save writes the table to disk atomically; callers must hold t.mu
+func (t *LastSeen) save() error {
+	if t.path == "" {
+		return nil
+	}
+
+	stored := make([]lastSeenEntry, 0, len(t.entries))
+	for _, entry := range t.entries {
+		stored = append(stored, entry)
+	}
+	sort.Slice(stored, func(i, j int) bool { return stored[i].Key < stored[j].Key })
+
+	data, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if dir := filepath.Dir(t.path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+
+	tmp := t.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, t.path)
+}
@@ -0,0 +1,94 @@
+package kafka
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLastSeen(t *testing.T) {
+	tests := []struct {
+		name      string
+		record    [][2]string // Key and message ID pairs, in delivery order
+		key       string
+		messageID string
+		want      bool
+	}{
+		{name: "empty table", key: "10001|current", messageID: "a"},
+		{name: "delivered message", record: [][2]string{{"10001|current", "a"}}, key: "10001|current", messageID: "a", want: true},
+		{name: "new observation", record: [][2]string{{"10001|current", "a"}}, key: "10001|current", messageID: "b"},
+		{name: "other slot", record: [][2]string{{"10001|daily|1", "a"}}, key: "10001|daily|2", messageID: "a"},
+		{
+			name:      "a later delivery replaces the slot's message",
+			record:    [][2]string{{"10001|current", "a"}, {"10001|current", "b"}},
+			key:       "10001|current",
+			messageID: "a",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table := NewLastSeen("", time.Hour)
+			for _, r := range tt.record {
+				table.Record(r[0], r[1])
+			}
+			if got := table.Seen(tt.key, tt.messageID); got != tt.want {
+				t.Errorf("Seen(%q, %q) = %v, want %v", tt.key, tt.messageID, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLastSeenKeepsOneEntryPerSlot(t *testing.T) {
+	table := NewLastSeen("", time.Hour)
+	for i := 0; i < 100; i++ {
+		table.Record("10001|current", string(rune('a'+i%26)))
+		table.Record("10001|forecast", string(rune('a'+i%26)))
+	}
+
+	if got := table.Len(); got != 2 {
+		t.Errorf("Len() = %d, want 2", got)
+	}
+}
+
+func TestLastSeenPersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "last_seen.json")
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	before := NewLastSeen(path, time.Hour)
+	before.now = func() time.Time { return now }
+	before.Record("10001|current", "a")
+
+	// Deliveries only update memory; the file is written on flush
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("table saved on Record: %v", err)
+	}
+	before.Record("90210|current", "b")
+	before.Close()
+
+	tests := []struct {
+		name    string
+		elapsed time.Duration
+		want    int
+	}{
+		{name: "within retention", elapsed: 30 * time.Minute, want: 2},
+		{name: "past retention", elapsed: 2 * time.Hour, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			after := NewLastSeen(path, time.Hour)
+			after.now = func() time.Time { return now.Add(tt.elapsed) }
+			if err := after.Load(); err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+
+			if got := after.Len(); got != tt.want {
+				t.Errorf("Len() = %d, want %d", got, tt.want)
+			}
+			if got := after.Seen("10001|current", "a"); got != (tt.want > 0) {
+				t.Errorf("Seen() = %v, want %v", got, tt.want > 0)
+			}
+		})
+	}
+}
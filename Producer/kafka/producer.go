@@ -2,209 +2,282 @@ package kafka
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"log"
+	"strconv"
 	"time"
 
+	"github.com/abhijeet1999/weather/codec"
+	"github.com/abhijeet1999/weather/kafkaconfig"
 	"github.com/abhijeet1999/weather/models"
 	"github.com/segmentio/kafka-go"
 )
 
 // KafkaProducer handles sending weather data to Kafka
 type KafkaProducer struct {
-	writer *kafka.Writer
-	topic  string
-}
-
-// NewKafkaProducer creates a new Kafka producer instance
-func NewKafkaProducer(bootstrapServers, topic string) (*KafkaProducer, error) {
-	writer := &kafka.Writer{
-		Addr:     kafka.TCP(bootstrapServers),
-		Topic:    topic,
-		Balancer: &kafka.LeastBytes{},
-		Async:    true,
-	}
-
-	log.Printf("📤 Kafka producer connected to %s, topic: %s", bootstrapServers, topic)
-
-	return &KafkaProducer{
-		writer: writer,
-		topic:  topic,
-	}, nil
-}
-
-// WeatherMessage represents the message structure sent to Kafka
-type WeatherMessage struct {
-	Timestamp   time.Time                           `json:"timestamp"`
-	ZipCode     string                              `json:"zip_code"`
-	City        string                              `json:"city"`
-	Country     string                              `json:"country"`
-	Current     *models.OpenWeatherResponse         `json:"current,omitempty"`
-	Forecast    *models.OpenWeatherForecastResponse `json:"forecast,omitempty"`
-	Hourly      *models.ForecastItem                `json:"hourly,omitempty"`
-	Daily       *DailyWeatherData                   `json:"daily,omitempty"`
-	MessageType string                              `json:"message_type"` // "current", "forecast", "hourly", "daily"
-}
-
-// DailyWeatherData represents daily weather summary
-type DailyWeatherData struct {
-	Day         int     `json:"day"`
-	Date        string  `json:"date"`
-	TempMin     float32 `json:"temp_min"`
-	TempMax     float32 `json:"temp_max"`
-	TempAvg     float32 `json:"temp_avg"`
-	Humidity    int     `json:"humidity"`
-	WindSpeed   float32 `json:"wind_speed"`
-	Description string  `json:"description"`
-	Icon        string  `json:"icon"`
-}
-
-// SendWeatherData sends weather data to Kafka topic
-func (kp *KafkaProducer) SendWeatherData(message WeatherMessage) error {
-	// Serialize message to JSON
-	jsonData, err := json.Marshal(message)
+	writer     *kafka.Writer
+	topics     kafkaconfig.Topics
+	codec      codec.Codec
+	schemaID   int // Registry ID of the schema messages are written with; 0 for JSON
+	deliveries *deliveryTracker
+	onDelivery DeliveryFunc
+	lastSeen   *LastSeen // Nil publishes every message
+}
+
+// NewKafkaProducer creates a new Kafka producer instance that encodes messages with messageCodec and sends
+// each message type to the topic it is routed to.
+// Codecs other than JSON need the schema registry to stamp each message with its schema ID.
+func NewKafkaProducer(conn *kafkaconfig.Connection, topics kafkaconfig.Topics, messageCodec codec.Codec, registry *codec.Registry) (*KafkaProducer, error) {
+	var schemaID int
+	if codec.RequiresSchema(messageCodec.Name()) {
+		schema, err := registry.Lookup(messageCodec.Name(), models.MessageSchemaVersion)
+		if err != nil {
+			return nil, err
+		}
+		schemaID = schema.ID
+	}
+
+	kp := &KafkaProducer{
+		topics:     topics,
+		codec:      messageCodec,
+		schemaID:   schemaID,
+		deliveries: newDeliveryTracker(),
+	}
+
+	// Writes are asynchronous; delivery results arrive in the Completion callback.
+	// Each message names its topic, so one writer serves every route.
+	kp.writer = &kafka.Writer{
+		Addr:                   conn.Addr(),
+		Transport:              conn.Transport(),
+		Balancer:               &kafka.LeastBytes{},
+		Async:                  true,
+		Completion:             kp.completion,
+		AllowAutoTopicCreation: true,
+	}
+
+	log.Printf("📤 Kafka producer connected to %s, topics: %s, codec: %s", conn, topics, messageCodec.Name())
+
+	return kp, nil
+}
+
+// OnDelivery sets a function called with the outcome of every message; set it before sending
+func (kp *KafkaProducer) OnDelivery(fn DeliveryFunc) {
+	kp.onDelivery = fn
+}
+
+// Deduplicate skips messages whose ID has already been delivered according to table; set it before sending
+func (kp *KafkaProducer) Deduplicate(table *LastSeen) {
+	kp.lastSeen = table
+}
+
+// PendingDeliveries returns the number of messages handed to the writer and not yet acknowledged or failed
+func (kp *KafkaProducer) PendingDeliveries() int {
+	return kp.deliveries.stats().Pending
+}
+
+// completion records the delivery result of a batch of asynchronously written messages
+func (kp *KafkaProducer) completion(messages []kafka.Message, err error) {
+	if err != nil {
+		log.Printf("❌ Failed to deliver %d messages to Kafka: %v", len(messages), err)
+	}
+
+	if kp.onDelivery != nil {
+		for _, msg := range messages {
+			kp.onDelivery(headerValue(msg, "message_type"), err)
+		}
+	}
+
+	// Only delivered messages count as seen, so failed ones are published again on the next poll
+	if err == nil && kp.lastSeen != nil {
+		for _, msg := range messages {
+			if slot, ok := msg.WriterData.(lastSeenSlot); ok {
+				kp.lastSeen.Record(slot.key, slot.messageID)
+			}
+		}
+	}
+
+	kp.deliveries.done(len(messages), err)
+}
+
+// headerValue returns the value of a message header, or "" if it is not set
+func headerValue(msg kafka.Message, key string) string {
+	for _, header := range msg.Headers {
+		if header.Key == key {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
+
+// lastSeenSlot identifies a message in the last-seen table; it travels with the message to the Completion callback
+type lastSeenSlot struct {
+	key       string
+	messageID string
+}
+
+// SendWeatherData sends weather data to Kafka topic, skipping observations that were already delivered
+func (kp *KafkaProducer) SendWeatherData(message models.WeatherMessage) error {
+	return kp.send(message, "")
+}
+
+// send sends weather data to Kafka topic unless its message ID is the last one delivered for the location,
+// message type and slot (the hour or day of a forecast; empty for a single message per type)
+func (kp *KafkaProducer) send(message models.WeatherMessage, slot string) error {
+	if message.SchemaVersion == "" {
+		message.SchemaVersion = models.MessageSchemaVersion
+	}
+
+	key := message.ZipCode + "|" + message.MessageType
+	if slot != "" {
+		key += "|" + slot
+	}
+	if kp.lastSeen != nil && message.MessageID != "" && kp.lastSeen.Seen(key, message.MessageID) {
+		log.Printf("⏭️ Skipping unchanged %s data for %s (%s): message %s already published",
+			message.MessageType, message.City, message.ZipCode, message.MessageID)
+		return nil
+	}
+
+	// Serialize message with the configured codec
+	data, err := kp.codec.Marshal(message)
 	if err != nil {
 		return fmt.Errorf("failed to marshal message: %v", err)
 	}
 
 	// Create Kafka message
 	kafkaMessage := kafka.Message{
+		Topic: kp.topics.For(message.MessageType),
 		Key:   []byte(message.ZipCode),
-		Value: jsonData,
+		Value: data,
 		Headers: []kafka.Header{
+			{Key: "codec", Value: []byte(kp.codec.Name())},
+			{Key: "schema_version", Value: []byte(message.SchemaVersion)},
+			{Key: "message_id", Value: []byte(message.MessageID)},
 			{Key: "message_type", Value: []byte(message.MessageType)},
 			{Key: "zip_code", Value: []byte(message.ZipCode)},
 			{Key: "city", Value: []byte(message.City)},
+			{Key: "units", Value: []byte(message.Units)},
 		},
 	}
+	if kp.schemaID > 0 {
+		kafkaMessage.Headers = append(kafkaMessage.Headers, kafka.Header{Key: "schema_id", Value: []byte(strconv.Itoa(kp.schemaID))})
+	}
+	if message.MessageID != "" {
+		kafkaMessage.WriterData = lastSeenSlot{key: key, messageID: message.MessageID}
+	}
 
 	// Send message
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
+	kp.deliveries.add(1)
 	err = kp.writer.WriteMessages(ctx, kafkaMessage)
 	if err != nil {
+		// Rejected before it was queued, so Completion will not report it
+		kp.completion([]kafka.Message{kafkaMessage}, err)
 		return fmt.Errorf("failed to produce message: %v", err)
 	}
 
-	log.Printf("📤 Sent weather data to Kafka: %s (%s) - %s",
-		message.City, message.ZipCode, message.MessageType)
+	log.Printf("📤 Sent weather data to Kafka: %s (%s) - %s → %s",
+		message.City, message.ZipCode, message.MessageType, kafkaMessage.Topic)
 
 	return nil
 }
 
 // SendCurrentWeather sends current weather data to Kafka
-func (kp *KafkaProducer) SendCurrentWeather(zipCode, city, country string, weather models.OpenWeatherResponse) error {
-	message := WeatherMessage{
+func (kp *KafkaProducer) SendCurrentWeather(zipCode, city, country, units string, weather models.OpenWeatherResponse) error {
+	message := models.WeatherMessage{
+		MessageID:   models.NewMessageID(zipCode, models.MessageTypeCurrent, weather.Dt, units),
 		Timestamp:   time.Now(),
 		ZipCode:     zipCode,
 		City:        city,
 		Country:     country,
+		Units:       units,
 		Current:     &weather,
-		MessageType: "current",
+		MessageType: models.MessageTypeCurrent,
+		Timezone:    models.TimezoneName(weather.Timezone),
+		UTCOffset:   weather.Timezone,
 	}
 
 	return kp.SendWeatherData(message)
 }
 
 // SendForecastWeather sends forecast weather data to Kafka
-func (kp *KafkaProducer) SendForecastWeather(zipCode, city, country string, forecast models.OpenWeatherForecastResponse) error {
-	message := WeatherMessage{
+func (kp *KafkaProducer) SendForecastWeather(zipCode, city, country, units string, forecast models.OpenWeatherForecastResponse) error {
+	message := models.WeatherMessage{
+		MessageID:   models.NewMessageID(zipCode, models.MessageTypeForecast, forecastIssuedAt(forecast), contentSlot("", units, forecast.List)),
 		Timestamp:   time.Now(),
 		ZipCode:     zipCode,
 		City:        city,
 		Country:     country,
+		Units:       units,
 		Forecast:    &forecast,
-		MessageType: "forecast",
+		MessageType: models.MessageTypeForecast,
+		Timezone:    models.TimezoneName(forecast.City.Timezone),
+		UTCOffset:   forecast.City.Timezone,
 	}
 
 	return kp.SendWeatherData(message)
 }
 
-// SendHourlyWeather sends individual hourly weather data to Kafka
-func (kp *KafkaProducer) SendHourlyWeather(zipCode, city, country string, hourly models.ForecastItem) error {
-	message := WeatherMessage{
+// SendHourlyWeather sends the forecast item at index as individual hourly weather data to Kafka
+func (kp *KafkaProducer) SendHourlyWeather(zipCode, city, country, units string, forecast models.OpenWeatherForecastResponse, index int) error {
+	hourly := forecast.List[index]
+	message := models.WeatherMessage{
+		MessageID:   models.NewMessageID(zipCode, models.MessageTypeHourly, forecastIssuedAt(forecast), contentSlot(strconv.FormatInt(hourly.Dt, 10), units, hourly)),
 		Timestamp:   time.Now(),
 		ZipCode:     zipCode,
 		City:        city,
 		Country:     country,
+		Units:       units,
 		Hourly:      &hourly,
-		MessageType: "hourly",
+		MessageType: models.MessageTypeHourly,
+		Timezone:    models.TimezoneName(forecast.City.Timezone),
+		UTCOffset:   forecast.City.Timezone,
 	}
 
-	return kp.SendWeatherData(message)
+	return kp.send(message, strconv.Itoa(index))
 }
 
-// SendDailyWeather sends daily weather summary to Kafka
-func (kp *KafkaProducer) SendDailyWeather(zipCode, city, country string, forecast models.OpenWeatherForecastResponse, day int) error {
-	// Calculate daily summary from forecast items for the specified day
-	dailyData := kp.calculateDailySummary(forecast, day)
-
-	message := WeatherMessage{
+// SendDailyWeather sends the daily weather summary of a forecast to Kafka
+func (kp *KafkaProducer) SendDailyWeather(zipCode, city, country, units string, forecast models.OpenWeatherForecastResponse, daily models.DailyWeatherData) error {
+	message := models.WeatherMessage{
+		MessageID:   models.NewMessageID(zipCode, models.MessageTypeDaily, forecastIssuedAt(forecast), contentSlot(daily.Date, units, daily)),
 		Timestamp:   time.Now(),
 		ZipCode:     zipCode,
 		City:        city,
 		Country:     country,
-		Daily:       dailyData,
-		MessageType: "daily",
+		Units:       units,
+		Daily:       &daily,
+		MessageType: models.MessageTypeDaily,
+		Timezone:    models.TimezoneName(forecast.City.Timezone),
+		UTCOffset:   forecast.City.Timezone,
 	}
 
-	return kp.SendWeatherData(message)
+	return kp.send(message, strconv.Itoa(daily.Day))
 }
 
-// calculateDailySummary calculates daily weather summary from forecast items
-func (kp *KafkaProducer) calculateDailySummary(forecast models.OpenWeatherForecastResponse, day int) *DailyWeatherData {
-	// Get forecast items for the specified day
-	var dayItems []models.ForecastItem
-	targetDate := time.Now().AddDate(0, 0, day-1).Format("2006-01-02")
-
-	for _, item := range forecast.List {
-		itemDate := time.Unix(item.Dt, 0).Format("2006-01-02")
-		if itemDate == targetDate {
-			dayItems = append(dayItems, item)
-		}
-	}
-
-	if len(dayItems) == 0 {
-		return &DailyWeatherData{
-			Day:         day,
-			Date:        targetDate,
-			Description: "No data available",
-		}
-	}
-
-	// Calculate summary statistics
-	var tempMin, tempMax, tempSum float32
-	var humiditySum int
-	var windSum float32
-
-	tempMin = dayItems[0].Main.TempMin
-	tempMax = dayItems[0].Main.TempMax
-
-	for _, item := range dayItems {
-		if item.Main.TempMin < tempMin {
-			tempMin = item.Main.TempMin
-		}
-		if item.Main.TempMax > tempMax {
-			tempMax = item.Main.TempMax
-		}
-		tempSum += item.Main.Temp
-		humiditySum += item.Main.Humidity
-		windSum += item.Wind.Speed
+// contentSlot extends a message ID slot with the units and a digest of the message content, so a provider update
+// within the same forecast slot, or a switch of units, yields a new message ID
+func contentSlot(slot, units string, content any) string {
+	data, err := json.Marshal(content)
+	if err != nil {
+		// Marshalling plain model structs does not fail; fall back to the slot and units alone
+		return slot + "|" + units
 	}
+	sum := sha256.Sum256(data)
+	return slot + "|" + units + "|" + hex.EncodeToString(sum[:8])
+}
 
-	return &DailyWeatherData{
-		Day:         day,
-		Date:        targetDate,
-		TempMin:     tempMin,
-		TempMax:     tempMax,
-		TempAvg:     tempSum / float32(len(dayItems)),
-		Humidity:    humiditySum / len(dayItems),
-		WindSpeed:   windSum / float32(len(dayItems)),
-		Description: dayItems[0].Weather[0].Description,
-		Icon:        dayItems[0].Weather[0].Icon,
+// forecastIssuedAt returns the observation time of a forecast. Providers do not report when a forecast was issued,
+// so its first time slot stands in: it moves forward whenever a newer forecast is published.
+func forecastIssuedAt(forecast models.OpenWeatherForecastResponse) int64 {
+	if len(forecast.List) == 0 {
+		return 0
 	}
+	return forecast.List[0].Dt
 }
 
 // Close closes the Kafka producer
@@ -212,18 +285,16 @@ func (kp *KafkaProducer) Close() {
 	kp.writer.Close()
 }
 
-// Flush ensures all messages are sent before closing
+// Flush blocks until every message handed to the writer has been acknowledged or has failed, or the timeout expires
 func (kp *KafkaProducer) Flush(timeoutMs int) {
-	// Segment kafka-go handles flushing automatically with Async: true
-	// For synchronous flushing, we can use a timeout context
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeoutMs)*time.Millisecond)
-	defer cancel()
+	timer := time.NewTimer(time.Duration(timeoutMs) * time.Millisecond)
+	defer timer.Stop()
 
-	// Wait for any pending writes to complete
 	select {
-	case <-ctx.Done():
-		log.Printf("⚠️ Flush timeout after %dms", timeoutMs)
-	default:
-		log.Printf("✅ Flush completed")
+	case <-kp.deliveries.waitIdle():
+		stats := kp.deliveries.stats()
+		log.Printf("✅ Flush completed: no messages pending (%d delivered, %d failed since startup)", stats.Delivered, stats.Failed)
+	case <-timer.C:
+		log.Printf("⚠️ Flush timeout after %dms with %d messages still pending", timeoutMs, kp.deliveries.stats().Pending)
 	}
 }
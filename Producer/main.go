@@ -1,198 +1,514 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/abhijeet1999/weather/Producer/horizon"
 	"github.com/abhijeet1999/weather/Producer/kafka"
+	"github.com/abhijeet1999/weather/Producer/pool"
+	producerMetrics "github.com/abhijeet1999/weather/Producer/prometheus"
+	"github.com/abhijeet1999/weather/Producer/scheduler"
 	"github.com/abhijeet1999/weather/Producer/utils"
 	"github.com/abhijeet1999/weather/Producer/weather"
+	"github.com/abhijeet1999/weather/codec"
+	"github.com/abhijeet1999/weather/kafkaconfig"
 	"github.com/abhijeet1999/weather/models"
 )
 
 func main() {
+	// Configuration from environment variables
+	weatherProvider := getEnvOrDefault("WEATHER_PROVIDER", weather.ProviderOpenWeatherMap)
+
 	// Check for required environment variables
 	apiKey := os.Getenv("WEATHER_API_KEY")
-	if apiKey == "" {
+	if apiKey == "" && weatherProvider == weather.ProviderOpenWeatherMap {
 		log.Fatal("❌ WEATHER_API_KEY environment variable is required")
 	}
 
-	// Configuration from environment variables
-	kafkaServers := getEnvOrDefault("KAFKA_SERVERS", "kafka:9092")
-	kafkaTopic := getEnvOrDefault("KAFKA_TOPIC", "weather_data")
+	kafkaConfig := kafkaconfig.FromEnv("kafka:9092", "weather-producer")
+	kafkaTopics := kafkaconfig.TopicsFromEnv("weather_data")
+	messageCodecName := getEnvOrDefault("KAFKA_MESSAGE_CODEC", codec.NameJSON)
+	schemaRegistryFile := getEnvOrDefault("SCHEMA_REGISTRY_FILE", "schemas/registry.yaml")
 	inputFile := getEnvOrDefault("INPUT_FILE", "input.txt")
+	currentInterval := getDurationEnvOrDefault("POLL_CURRENT_INTERVAL", 10*time.Minute)
+	forecastInterval := getDurationEnvOrDefault("POLL_FORECAST_INTERVAL", 3*time.Hour)
+	pollJitter := getFloatEnvOrDefault("POLL_JITTER", 0.1)
+	metricsPort := getEnvOrDefault("PRODUCER_METRICS_PORT", "8082")
+	callsPerMinute := getIntEnvOrDefault("WEATHER_API_CALLS_PER_MINUTE", 60)
+	dailyQuota := getIntEnvOrDefault("WEATHER_API_DAILY_QUOTA", 1000)
+	quotaReserve := getFloatEnvOrDefault("WEATHER_API_QUOTA_RESERVE", 0.1)
+	workerConcurrency := getIntEnvOrDefault("WORKER_CONCURRENCY", 4)
+	reloadInterval := getDurationEnvOrDefault("INPUT_RELOAD_INTERVAL", 15*time.Second)
+	lastSeenFile := getEnvOrDefault("LAST_SEEN_FILE", "last_seen.json")
+	dedupWindow := getDurationEnvOrDefault("DEDUP_WINDOW", 48*time.Hour)
+	healthStaleAfter := getDurationEnvOrDefault("HEALTH_STALE_AFTER", 3*currentInterval)
+	hourlyHorizon := getDurationEnvOrDefault("FORECAST_HOURLY_HORIZON", horizon.DefaultHourlyHorizon)
+	extendedDays := getIntEnvOrDefault("FORECAST_EXTENDED_DAYS", horizon.DefaultExtendedDays)
 
 	log.Println("🚀 Starting Weather Producer...")
-	log.Printf("📤 Kafka Servers: %s", kafkaServers)
-	log.Printf("📤 Kafka Topic: %s", kafkaTopic)
-	log.Printf("📄 Input File: %s", inputFile)
+	log.Printf("📤 Kafka Servers: %s", strings.Join(kafkaConfig.Brokers, ","))
+	log.Printf("📤 Kafka Topics: %s", kafkaTopics)
+	log.Printf("📄 Input File: %s (checked for changes every %s, or on SIGHUP)", inputFile, reloadInterval)
+	log.Printf("🌦️ Weather Provider: %s", weatherProvider)
+	log.Printf("⏰ Poll Intervals: current=%s, forecast=%s, jitter=%.0f%%", currentInterval, forecastInterval, pollJitter*100)
+	log.Printf("🚦 API Limits: %d calls/min, %d calls/day (%.0f%% reserved for normal/high priority)", callsPerMinute, dailyQuota, quotaReserve*100)
+	log.Printf("👷 Worker Concurrency: %d", workerConcurrency)
+	log.Printf("🗓️ Forecast Split: %d+ day requests get %s of hourly data, shorter ones a forecast message; all get daily summaries", extendedDays, hourlyHorizon)
+
+	// Shared rate limiter and daily quota for all weather API calls
+	rateLimiter := weather.NewRateLimiter(callsPerMinute, 5)
+	quota := weather.NewQuotaTracker(dailyQuota, quotaReserve)
+
+	// Start Prometheus metrics and health server; it keeps serving until pending messages are flushed
+	metrics := producerMetrics.NewProducerMetrics(quota, healthStaleAfter)
+	metricsCtx, stopMetrics := context.WithCancel(context.Background())
+	defer stopMetrics()
+	metricsStopped := make(chan struct{})
+	go func() {
+		defer close(metricsStopped)
+		if err := metrics.StartMetricsServer(metricsCtx, metricsPort, 5*time.Second); err != nil {
+			log.Printf("❌ %v", err)
+		}
+	}()
 
 	// Initialize weather service
-	weatherService := weather.NewWeatherService()
+	weatherService := weather.NewWeatherService(weatherServiceOptions(rateLimiter, quota, metrics)...)
+	if err := weatherService.SetDefaultProvider(weatherProvider); err != nil {
+		log.Fatalf("❌ Invalid WEATHER_PROVIDER: %v", err)
+	}
+
+	// Root context cancelled on shutdown
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Message codec; binary codecs need the schema registry to agree on schema IDs with the consumer
+	messageCodec, err := codec.New(messageCodecName)
+	if err != nil {
+		log.Fatalf("❌ Invalid KAFKA_MESSAGE_CODEC: %v", err)
+	}
+	var schemaRegistry *codec.Registry
+	if codec.RequiresSchema(messageCodec.Name()) {
+		schemaRegistry, err = codec.LoadRegistry(schemaRegistryFile)
+		if err != nil {
+			log.Fatalf("❌ Failed to load schema registry: %v", err)
+		}
+	}
 
 	// Initialize Kafka producer
-	producer, err := kafka.NewKafkaProducer(kafkaServers, kafkaTopic)
+	kafkaConn, err := kafkaconfig.NewConnection(kafkaConfig)
+	if err != nil {
+		log.Fatalf("❌ Invalid Kafka connection settings: %v", err)
+	}
+	producer, err := kafka.NewKafkaProducer(kafkaConn, kafkaTopics, messageCodec, schemaRegistry)
 	if err != nil {
 		log.Fatalf("❌ Failed to create Kafka producer: %v", err)
 	}
 	defer producer.Close()
+	producer.OnDelivery(metrics.RecordDelivery)
+	metrics.ObserveDeliveries(producer)
+
+	// Observations already delivered, so unchanged data is not republished after a restart
+	lastSeen := kafka.NewLastSeen(lastSeenFile, dedupWindow)
+	if err := lastSeen.Load(); err != nil {
+		log.Printf("⚠️ Starting with an empty last-seen table: %v", err)
+	}
+	producer.Deduplicate(lastSeen)
+	go lastSeen.Run(ctx)
+
+	// Decides which forecast messages cover the days each location requests
+	planner := horizon.NewPlanner(horizon.Config{
+		HourlyHorizon: hourlyHorizon,
+		ExtendedDays:  extendedDays,
+	})
+
+	// Bounded worker pool shared by the initial batch and scheduled polls
+	workerPool := pool.NewPool(pool.Config{
+		Concurrency: workerConcurrency,
+		ShouldAbort: shouldAbortBatch,
+		IsSkipped: func(err error) bool {
+			return errors.Is(err, weather.ErrDeferred)
+		},
+	})
+
+	// Initialize scheduler for continuous polling
+	pollScheduler := scheduler.NewScheduler(
+		scheduler.Config{
+			CurrentInterval:  currentInterval,
+			ForecastInterval: forecastInterval,
+			Jitter:           pollJitter,
+		},
+		func(ctx context.Context, req models.WeatherRequest) error {
+			if deferForQuota(weatherService, metrics, req) {
+				return weather.ErrDeferred
+			}
+			return recordPoll(metrics, req, pollKindCurrent, workerPool.Do(ctx, func() error {
+				return processCurrentWeather(ctx, weatherService, producer, req)
+			}))
+		},
+		func(ctx context.Context, req models.WeatherRequest) error {
+			if deferForQuota(weatherService, metrics, req) {
+				return weather.ErrDeferred
+			}
+			return recordPoll(metrics, req, pollKindForecast, workerPool.Do(ctx, func() error {
+				return processForecastWeather(ctx, weatherService, producer, planner, req)
+			}))
+		},
+	)
+
+	// Reload locations when the input file changes, polling new locations right away
+	watcher := utils.NewLocationsWatcher(inputFile, reloadInterval, func(requests []models.WeatherRequest) error {
+		if err := validateProviders(weatherService, requests); err != nil {
+			return err
+		}
+
+		metrics.SetLocations(locationIDs(requests))
+		newLocations := pollScheduler.Reload(requests)
+		if len(newLocations) > 0 {
+			go func() {
+				log.Printf("🚀 Processing %d new locations...", len(newLocations))
+				summary := workerPool.Run(ctx, newLocations, func(ctx context.Context, req models.WeatherRequest) error {
+					return pollLocation(ctx, weatherService, producer, planner, metrics, req)
+				})
+				logBatchSummary("New locations batch", summary)
+				metrics.RecordBatch("reload", summary.Succeeded, summary.Failed, summary.Skipped, summary.Cancelled)
+			}()
+		}
+		return nil
+	})
 
-	// Process initial batch from input file
+	// Process initial batch from input file, then keep polling on schedule
 	go func() {
 		time.Sleep(2 * time.Second) // Wait for Kafka to be ready
-		processInitialBatch(weatherService, producer, inputFile)
+		requests := processInitialBatch(ctx, weatherService, producer, planner, metrics, workerPool, inputFile)
+		if len(requests) > 0 {
+			pollScheduler.Start(requests)
+		}
+		watcher.Run(ctx)
 	}()
 
 	log.Println("✅ Weather Producer started successfully!")
 	log.Println("⏹️  Press Ctrl+C to stop...")
 
+	// SIGHUP reloads the input file on demand
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			log.Println("🔄 Received SIGHUP, reloading input file...")
+			watcher.Reload()
+		}
+	}()
+
 	// Wait for interrupt signal
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
 	<-c
 
 	log.Println("🛑 Shutting down Weather Producer...")
-	producer.Flush(1000) // Flush remaining messages
+	cancel()
+	pollScheduler.Stop()
+	producer.Flush(10000) // Wait for pending messages to be acknowledged
+	lastSeen.Close()      // Save the IDs of the messages just acknowledged
+	stopMetrics()
+	<-metricsStopped
 	log.Println("✅ Shutdown complete")
 }
 
-// processInitialBatch processes the initial batch from input file
-func processInitialBatch(weatherService *weather.WeatherService, producer *kafka.KafkaProducer, inputFile string) {
+// processInitialBatch processes the initial batch from input file in parallel and returns the parsed requests
+func processInitialBatch(ctx context.Context, weatherService *weather.WeatherService, producer *kafka.KafkaProducer, planner *horizon.Planner, metrics *producerMetrics.ProducerMetrics, workerPool *pool.Pool, inputFile string) []models.WeatherRequest {
 	log.Printf("📋 Processing initial batch from %s...", inputFile)
 
-	// Parse input file
-	requests, err := utils.ParseInputFile(inputFile)
+	// Load locations from input.txt or a YAML/JSON config file
+	requests, err := utils.LoadLocations(inputFile)
 	if err != nil {
 		log.Printf("❌ Error parsing input file: %v", err)
 		log.Printf("⚠️ Skipping initial batch processing - check input file format")
-		return
+		return nil
+	}
+
+	if err := validateProviders(weatherService, requests); err != nil {
+		log.Printf("❌ Error in input file: %v", err)
+		log.Printf("⚠️ Skipping initial batch processing - check input file providers")
+		return nil
 	}
 
 	log.Printf("🚀 Processing %d weather requests...", len(requests))
+	metrics.SetLocations(locationIDs(requests))
+
+	summary := workerPool.Run(ctx, requests, func(ctx context.Context, req models.WeatherRequest) error {
+		return pollLocation(ctx, weatherService, producer, planner, metrics, req)
+	})
 
-	// Process each request
-	successCount := 0
-	for i, req := range requests {
-		log.Printf("📤 Processing request %d: %s (%d days)", i+1, req.ZipCode, req.Days)
-
-		// Process based on days requirement
-		var err error
-		if req.Days >= 4 {
-			// For 4+ days: Send hourly data for 48 hours + daily data for remaining days
-			err = processExtendedWeatherData(weatherService, producer, req)
-		} else {
-			// For 1-3 days: Use existing logic
-			err = processStandardWeatherData(weatherService, producer, req)
+	logBatchSummary("Initial batch", summary)
+	metrics.RecordBatch("initial", summary.Succeeded, summary.Failed, summary.Skipped, summary.Cancelled)
+	return requests
+}
+
+// pollLocation fetches current weather and forecast for one location and sends them to Kafka
+func pollLocation(ctx context.Context, weatherService *weather.WeatherService, producer *kafka.KafkaProducer, planner *horizon.Planner, metrics *producerMetrics.ProducerMetrics, req models.WeatherRequest) error {
+	if deferForQuota(weatherService, metrics, req) {
+		return weather.ErrDeferred
+	}
+
+	log.Printf("📤 Processing request: %s (%d days)", req.LocationID(), req.Days)
+	if err := recordPoll(metrics, req, pollKindCurrent, processCurrentWeather(ctx, weatherService, producer, req)); err != nil {
+		return err
+	}
+	return recordPoll(metrics, req, pollKindForecast, processForecastWeather(ctx, weatherService, producer, planner, req))
+}
+
+// Poll kinds reported in metrics
+const (
+	pollKindCurrent  = "current"
+	pollKindForecast = "forecast"
+)
+
+// recordPoll reports the outcome of a poll to the metrics and health endpoint and returns err.
+// Deferred and cancelled polls are neither successes nor failures.
+func recordPoll(metrics *producerMetrics.ProducerMetrics, req models.WeatherRequest, kind string, err error) error {
+	if !errors.Is(err, weather.ErrDeferred) && !errors.Is(err, context.Canceled) {
+		metrics.RecordPoll(req.LocationID(), kind, err)
+	}
+	return err
+}
+
+// locationIDs returns the location ID of every request
+func locationIDs(requests []models.WeatherRequest) []string {
+	ids := make([]string, 0, len(requests))
+	for _, req := range requests {
+		ids = append(ids, req.LocationID())
+	}
+	return ids
+}
+
+// validateProviders checks that every location names a registered weather provider
+func validateProviders(weatherService *weather.WeatherService, requests []models.WeatherRequest) error {
+	for _, req := range requests {
+		if _, err := weatherService.WithProvider(req.Provider); err != nil {
+			return fmt.Errorf("location %s: %w", req.LocationID(), err)
 		}
+	}
+	return nil
+}
 
-		if err != nil {
-			log.Printf("❌ Failed to process weather for %s: %v", req.ZipCode, err)
-			continue
+// logBatchSummary logs the outcome of every location and the overall batch result
+func logBatchSummary(batch string, summary pool.Summary) {
+	for _, result := range summary.Results {
+		switch result.Status {
+		case pool.StatusSucceeded:
+			log.Printf("  ✅ %s: succeeded in %s", result.Request.LocationID(), result.Duration.Round(time.Millisecond))
+		case pool.StatusSkipped:
+			log.Printf("  ⏸️ %s: skipped (%v)", result.Request.LocationID(), result.Err)
+		case pool.StatusCancelled:
+			if result.Duration > 0 {
+				log.Printf("  🚫 %s: cancelled after %s", result.Request.LocationID(), result.Duration.Round(time.Millisecond))
+			} else {
+				log.Printf("  🚫 %s: cancelled before start", result.Request.LocationID())
+			}
+		default:
+			log.Printf("  ❌ %s: failed after %s: %v", result.Request.LocationID(), result.Duration.Round(time.Millisecond), result.Err)
 		}
+	}
 
-		successCount++
-		// Small delay between requests
-		time.Sleep(100 * time.Millisecond)
+	log.Printf("✅ %s processing completed in %s: %d/%d requests successful, %d failed, %d deferred, %d cancelled",
+		batch, summary.Duration.Round(time.Millisecond), summary.Succeeded, summary.Total, summary.Failed, summary.Skipped, summary.Cancelled)
+}
+
+// deferForQuota reports whether a location should be skipped to preserve the daily API quota
+func deferForQuota(weatherService *weather.WeatherService, metrics *producerMetrics.ProducerMetrics, req models.WeatherRequest) bool {
+	if weatherService.AllowsPriority(req.Priority) {
+		return false
+	}
+
+	priority := req.Priority
+	if priority == "" {
+		priority = models.PriorityNormal
 	}
 
-	log.Printf("✅ Initial batch processing completed: %d/%d requests successful", successCount, len(requests))
+	log.Printf("⏸️ Deferring %s-priority poll for %s to preserve daily API quota", priority, req.LocationID())
+	metrics.IncrementPollsDeferred(req.LocationID(), priority)
+	return true
 }
 
-// processExtendedWeatherData handles 4+ days with hourly data for first 48 hours
-func processExtendedWeatherData(weatherService *weather.WeatherService, producer *kafka.KafkaProducer, req models.WeatherRequest) error {
-	log.Printf("🕐 Processing extended weather data for %s (%d days)", req.ZipCode, req.Days)
+// shouldAbortBatch reports whether a weather API error makes the remaining requests pointless
+func shouldAbortBatch(err error) bool {
+	var apiErr *weather.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
 
-	// Get current weather
-	currentWeather, err := weatherService.GetWeatherByZip(req.ZipCode, "US", "metric")
-	if err != nil {
-		return err
+	switch apiErr.Kind {
+	case weather.ErrorKindUnauthorized:
+		// Every remaining call would be rejected with the same API key
+		return true
+	case weather.ErrorKindRateLimited, weather.ErrorKindQuota:
+		// Retries or budget are exhausted; the scheduler will pick the locations up on the next poll
+		return true
+	default:
+		// Not found, decode and server errors only affect this location
+		return false
 	}
+}
 
-	// Send current weather
-	err = producer.SendCurrentWeather(req.ZipCode, currentWeather.Name, "US", currentWeather)
+// processCurrentWeather fetches current weather and sends it to Kafka
+func processCurrentWeather(ctx context.Context, weatherService *weather.WeatherService, producer *kafka.KafkaProducer, req models.WeatherRequest) error {
+	weatherService, err := weatherService.WithProvider(req.Provider)
 	if err != nil {
-		log.Printf("❌ Failed to send current weather for %s: %v", req.ZipCode, err)
+		return err
 	}
 
-	// Get 5-day forecast for hourly data
-	forecast, err := weatherService.GetForecastByZip(req.ZipCode, "US", "metric")
+	units := utils.NormalizeUnits(req.Units)
+	currentWeather, err := weatherService.GetWeatherForLocation(ctx, req, units)
 	if err != nil {
 		return err
 	}
 
-	// Send hourly data for first 48 hours (every 3 hours = 16 data points)
-	hourlyCount := 0
-	for _, item := range forecast.List {
-		if hourlyCount >= 16 { // 48 hours / 3 hours per forecast = 16 items
-			break
-		}
+	err = producer.SendCurrentWeather(req.LocationID(), displayName(req, currentWeather.Name), req.Country, units, currentWeather)
+	if err != nil {
+		log.Printf("❌ Failed to send current weather to Kafka for %s: %v", req.LocationID(), err)
+	}
 
-		// Send individual forecast item as hourly data
-		err = producer.SendHourlyWeather(req.ZipCode, forecast.City.Name, "US", item)
-		if err != nil {
-			log.Printf("❌ Failed to send hourly weather for %s: %v", req.ZipCode, err)
-		}
+	return nil
+}
 
-		hourlyCount++
-		log.Printf("📊 Sent hourly data %d/16 for %s: %.1f°C", hourlyCount, req.ZipCode, item.Main.Temp)
+// displayName returns the configured location name, falling back to the name reported by the provider
+func displayName(req models.WeatherRequest, providerName string) string {
+	if req.Name != "" {
+		return req.Name
+	}
+	return providerName
+}
 
-		// Small delay between hourly sends
-		time.Sleep(50 * time.Millisecond)
+// processForecastWeather fetches the forecast and sends the messages the planner chooses for the requested days
+func processForecastWeather(ctx context.Context, weatherService *weather.WeatherService, producer *kafka.KafkaProducer, planner *horizon.Planner, req models.WeatherRequest) error {
+	if req.Days <= 0 {
+		return nil
 	}
 
-	// Send daily summaries for remaining days (3rd and 4th day)
-	if req.Days >= 3 {
-		err = producer.SendDailyWeather(req.ZipCode, forecast.City.Name, "US", forecast, 3)
-		if err != nil {
-			log.Printf("❌ Failed to send daily weather (day 3) for %s: %v", req.ZipCode, err)
-		}
+	weatherService, err := weatherService.WithProvider(req.Provider)
+	if err != nil {
+		return err
 	}
 
-	if req.Days >= 4 {
-		err = producer.SendDailyWeather(req.ZipCode, forecast.City.Name, "US", forecast, 4)
-		if err != nil {
-			log.Printf("❌ Failed to send daily weather (day 4) for %s: %v", req.ZipCode, err)
-		}
+	units := utils.NormalizeUnits(req.Units)
+	forecast, err := weatherService.GetForecastForLocation(ctx, req, units)
+	if err != nil {
+		return err
 	}
 
-	log.Printf("✅ Extended weather data completed for %s: %d hourly + %d daily",
-		req.ZipCode, hourlyCount, req.Days-2)
+	plan := planner.Plan(forecast, req.Days)
+	if plan.Extended {
+		// Extended requests: hourly data for the hourly horizon, then daily summaries
+		processExtendedWeatherData(producer, req, units, plan)
+	} else {
+		// Shorter requests: the forecast trimmed to the requested days
+		processStandardWeatherData(producer, req, units, plan)
+	}
+	sendDailySummaries(producer, req, units, plan)
 
 	return nil
 }
 
-// processStandardWeatherData handles 1-3 days with existing logic
-func processStandardWeatherData(weatherService *weather.WeatherService, producer *kafka.KafkaProducer, req models.WeatherRequest) error {
-	// Fetch current weather
-	weather, err := weatherService.GetWeatherByZip(req.ZipCode, "US", "metric")
-	if err != nil {
-		return err
+// processExtendedWeatherData sends each forecast item within the hourly horizon as hourly data
+func processExtendedWeatherData(producer *kafka.KafkaProducer, req models.WeatherRequest, units string, plan horizon.Plan) {
+	log.Printf("🕐 Processing extended weather data for %s (%d days)", req.LocationID(), req.Days)
+
+	forecast := plan.Forecast
+	for i := 0; i < plan.Hourly; i++ {
+		err := producer.SendHourlyWeather(req.LocationID(), displayName(req, forecast.City.Name), req.Country, units, forecast, i)
+		if err != nil {
+			log.Printf("❌ Failed to send hourly weather for %s: %v", req.LocationID(), err)
+			continue
+		}
+		log.Printf("📊 Sent hourly data %d/%d for %s: %.1f%s", i+1, plan.Hourly, req.LocationID(), forecast.List[i].Main.Temp, utils.TemperatureSymbol(units))
 	}
+}
 
-	// Send current weather to Kafka
-	err = producer.SendCurrentWeather(req.ZipCode, weather.Name, "US", weather)
+// processStandardWeatherData sends the forecast trimmed to the requested days
+func processStandardWeatherData(producer *kafka.KafkaProducer, req models.WeatherRequest, units string, plan horizon.Plan) {
+	forecast := plan.Forecast
+	err := producer.SendForecastWeather(req.LocationID(), displayName(req, forecast.City.Name), req.Country, units, forecast)
 	if err != nil {
-		log.Printf("❌ Failed to send current weather to Kafka for %s: %v", req.ZipCode, err)
+		log.Printf("❌ Failed to send forecast to Kafka for %s: %v", req.LocationID(), err)
 	}
+}
 
-	// Fetch forecast if requested
-	if req.Days > 0 {
-		forecast, err := weatherService.GetForecastByZip(req.ZipCode, "US", "metric")
-		if err != nil {
-			return err
+// sendDailySummaries sends a daily summary for every requested day that has forecast data
+func sendDailySummaries(producer *kafka.KafkaProducer, req models.WeatherRequest, units string, plan horizon.Plan) {
+	forecast := plan.Forecast
+	sent := 0
+	for _, day := range plan.Days {
+		summary := day.Summary()
+		if summary == nil {
+			log.Printf("⚠️ No forecast data for %s on day %d (%s), skipping its daily summary", req.LocationID(), day.Number, day.Date)
+			continue
 		}
 
-		// Send forecast to Kafka
-		err = producer.SendForecastWeather(req.ZipCode, forecast.City.Name, "US", forecast)
+		err := producer.SendDailyWeather(req.LocationID(), displayName(req, forecast.City.Name), req.Country, units, forecast, *summary)
 		if err != nil {
-			log.Printf("❌ Failed to send forecast to Kafka for %s: %v", req.ZipCode, err)
+			log.Printf("❌ Failed to send daily weather (day %d) for %s: %v", day.Number, req.LocationID(), err)
+			continue
 		}
+		sent++
 	}
 
-	return nil
+	log.Printf("✅ Forecast data completed for %s: %d forecast slots, %d hourly + %d/%d daily",
+		req.LocationID(), len(forecast.List), plan.Hourly, sent, len(plan.Days))
+}
+
+// weatherServiceOptions builds WeatherService options from environment variables
+func weatherServiceOptions(rateLimiter *weather.RateLimiter, quota *weather.QuotaTracker, metrics *producerMetrics.ProducerMetrics) []weather.Option {
+	retryPolicy := weather.DefaultRetryPolicy
+	retryPolicy.MaxAttempts = getIntEnvOrDefault("WEATHER_RETRY_ATTEMPTS", retryPolicy.MaxAttempts)
+
+	// Geocode cache so ZIP lookups survive restarts
+	geoCache := weather.NewGeoCache(
+		getIntEnvOrDefault("GEOCODE_CACHE_SIZE", 1000),
+		getDurationEnvOrDefault("GEOCODE_CACHE_TTL", 30*24*time.Hour),
+		getEnvOrDefault("GEOCODE_CACHE_FILE", "geocode_cache.json"),
+	)
+	if err := geoCache.Load(); err != nil {
+		log.Printf("⚠️ Starting with an empty geocode cache: %v", err)
+	}
+
+	opts := []weather.Option{
+		weather.WithUserAgent(getEnvOrDefault("WEATHER_USER_AGENT", weather.DefaultUserAgent)),
+		weather.WithRetryPolicy(retryPolicy),
+		weather.WithGeoCache(geoCache),
+		weather.WithRateLimiter(rateLimiter),
+		weather.WithQuota(quota),
+		weather.WithRequestObserver(func(endpoint string, duration time.Duration, err *weather.APIError) {
+			errorClass := ""
+			if err != nil {
+				errorClass = string(err.Kind)
+			}
+			metrics.ObserveAPIRequest(endpoint, duration, errorClass)
+		}),
+	}
+
+	if baseURL := os.Getenv("WEATHER_API_BASE_URL"); baseURL != "" {
+		log.Printf("🔗 OpenWeatherMap base URL: %s", baseURL)
+		opts = append(opts, weather.WithBaseURL(weather.ProviderOpenWeatherMap, baseURL))
+	}
+	if baseURL := os.Getenv("OPEN_METEO_BASE_URL"); baseURL != "" {
+		log.Printf("🔗 Open-Meteo base URL: %s", baseURL)
+		opts = append(opts, weather.WithBaseURL(weather.ProviderOpenMeteo, baseURL))
+	}
+	if baseURL := os.Getenv("OPEN_METEO_GEO_BASE_URL"); baseURL != "" {
+		log.Printf("🔗 Open-Meteo geocoding base URL: %s", baseURL)
+		opts = append(opts, weather.WithGeoBaseURL(weather.ProviderOpenMeteo, baseURL))
+	}
+
+	return opts
 }
 
 // getEnvOrDefault returns environment variable value or default
@@ -202,3 +518,48 @@ func getEnvOrDefault(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getDurationEnvOrDefault returns environment variable parsed as a duration or default
+func getDurationEnvOrDefault(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		log.Printf("⚠️ Invalid %s value '%s', using default %s", key, value, defaultValue)
+		return defaultValue
+	}
+	return duration
+}
+
+// getFloatEnvOrDefault returns environment variable parsed as a float or default
+func getFloatEnvOrDefault(key string, defaultValue float64) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || f < 0 {
+		log.Printf("⚠️ Invalid %s value '%s', using default %.2f", key, value, defaultValue)
+		return defaultValue
+	}
+	return f
+}
+
+// getIntEnvOrDefault returns environment variable parsed as an integer or default
+func getIntEnvOrDefault(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	i, err := strconv.Atoi(value)
+	if err != nil || i < 0 {
+		log.Printf("⚠️ Invalid %s value '%s', using default %d", key, value, defaultValue)
+		return defaultValue
+	}
+	return i
+}
@@ -0,0 +1,177 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/abhijeet1999/weather/models"
+)
+
+// Job processes a single weather request
+type Job func(ctx context.Context, req models.WeatherRequest) error
+
+// Result statuses
+const (
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+	StatusSkipped   = "skipped"
+	StatusCancelled = "cancelled"
+)
+
+// Config holds worker pool settings
+type Config struct {
+	Concurrency int // Maximum number of requests processed at once
+	// ShouldAbort reports whether an error cancels the requests that have not started yet
+	ShouldAbort func(error) bool
+	// IsSkipped reports whether an error means the request was intentionally skipped
+	IsSkipped func(error) bool
+}
+
+// Result is the outcome of processing a single weather request
+type Result struct {
+	Request  models.WeatherRequest
+	Status   string
+	Err      error
+	Duration time.Duration
+}
+
+// Summary aggregates the results of a batch run
+type Summary struct {
+	Total     int
+	Succeeded int
+	Failed    int
+	Skipped   int
+	Cancelled int
+	Duration  time.Duration
+	Results   []Result // In the same order as the input requests
+}
+
+// Pool processes weather requests in parallel with bounded concurrency
+type Pool struct {
+	config Config
+	slots  chan struct{}
+}
+
+// NewPool creates a new Pool instance
+func NewPool(config Config) *Pool {
+	if config.Concurrency < 1 {
+		config.Concurrency = 1
+	}
+
+	return &Pool{
+		config: config,
+		slots:  make(chan struct{}, config.Concurrency),
+	}
+}
+
+// Run processes all requests and waits for them to finish.
+// Requests not yet started when ctx is cancelled or the batch is aborted are reported as cancelled, as are running
+// requests that stop with a cancellation error.
+func (p *Pool) Run(ctx context.Context, requests []models.WeatherRequest, job Job) Summary {
+	start := time.Now()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]Result, len(requests))
+	var wg sync.WaitGroup
+
+	for i, req := range requests {
+		if err := p.acquire(ctx); err != nil {
+			results[i] = Result{Request: req, Status: StatusCancelled, Err: err}
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, req models.WeatherRequest) {
+			defer wg.Done()
+			defer p.release()
+
+			started := time.Now()
+			err := job(ctx, req)
+			results[i] = Result{
+				Request:  req,
+				Status:   p.status(ctx, err),
+				Err:      err,
+				Duration: time.Since(started),
+			}
+
+			if err != nil && p.config.ShouldAbort != nil && p.config.ShouldAbort(err) {
+				cancel()
+			}
+		}(i, req)
+	}
+
+	wg.Wait()
+	return summarize(results, time.Since(start))
+}
+
+// Do runs a single function in a pool slot, sharing the concurrency limit with Run
+func (p *Pool) Do(ctx context.Context, fn func() error) error {
+	if err := p.acquire(ctx); err != nil {
+		return err
+	}
+	defer p.release()
+
+	return fn()
+}
+
+// acquire waits for a free slot
+func (p *Pool) acquire(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	select {
+	case p.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release frees a slot
+func (p *Pool) release() {
+	<-p.slots
+}
+
+// status classifies a job error. Jobs stopped by cancellation, or by the batch deadline, count as cancelled rather
+// than failed; a deadline of the job's own, such as a request timeout, is still a failure.
+func (p *Pool) status(ctx context.Context, err error) string {
+	switch {
+	case err == nil:
+		return StatusSucceeded
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded) && ctx.Err() != nil:
+		return StatusCancelled
+	case p.config.IsSkipped != nil && p.config.IsSkipped(err):
+		return StatusSkipped
+	default:
+		return StatusFailed
+	}
+}
+
+// summarize counts results by status
+func summarize(results []Result, duration time.Duration) Summary {
+	summary := Summary{
+		Total:    len(results),
+		Duration: duration,
+		Results:  results,
+	}
+
+	for _, result := range results {
+		switch result.Status {
+		case StatusSucceeded:
+			summary.Succeeded++
+		case StatusSkipped:
+			summary.Skipped++
+		case StatusCancelled:
+			summary.Cancelled++
+		default:
+			summary.Failed++
+		}
+	}
+
+	return summary
+}
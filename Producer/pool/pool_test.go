@@ -0,0 +1,142 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/abhijeet1999/weather/models"
+)
+
+var (
+	errSkipped = errors.New("skipped")
+	errFatal   = errors.New("fatal")
+)
+
+func TestPoolStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "success", err: nil, want: StatusSucceeded},
+		{name: "failure", err: errors.New("boom"), want: StatusFailed},
+		{name: "skipped", err: fmt.Errorf("quota: %w", errSkipped), want: StatusSkipped},
+		{name: "own request timeout", err: fmt.Errorf("request: %w", context.DeadlineExceeded), want: StatusFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewPool(Config{Concurrency: 1, IsSkipped: func(err error) bool { return errors.Is(err, errSkipped) }})
+			summary := p.Run(context.Background(), []models.WeatherRequest{{ZipCode: "10001"}},
+				func(ctx context.Context, req models.WeatherRequest) error { return tt.err })
+
+			if got := summary.Results[0].Status; got != tt.want {
+				t.Errorf("status = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPoolCancellation(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout time.Duration // Batch deadline; 0 cancels the batch instead
+	}{
+		{name: "batch cancelled"},
+		{name: "batch deadline", timeout: 20 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.timeout > 0 {
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+
+			started := make(chan struct{})
+			go func() {
+				<-started
+				if tt.timeout == 0 {
+					cancel()
+				}
+			}()
+
+			// One slot: the first request runs until the batch stops, the second never starts
+			p := NewPool(Config{Concurrency: 1})
+			summary := p.Run(ctx, []models.WeatherRequest{{ZipCode: "10001"}, {ZipCode: "90210"}},
+				func(ctx context.Context, req models.WeatherRequest) error {
+					close(started)
+					<-ctx.Done()
+					return fmt.Errorf("fetch %s: %w", req.ZipCode, ctx.Err())
+				})
+
+			if summary.Cancelled != 2 || summary.Failed != 0 {
+				t.Fatalf("cancelled, failed = %d, %d, want 2, 0", summary.Cancelled, summary.Failed)
+			}
+			if summary.Results[0].Duration == 0 {
+				t.Errorf("running request has no duration")
+			}
+			if summary.Results[1].Duration != 0 {
+				t.Errorf("request that never started has duration %s", summary.Results[1].Duration)
+			}
+		})
+	}
+}
+
+func TestPoolAbortCancelsPendingRequests(t *testing.T) {
+	p := NewPool(Config{Concurrency: 1, ShouldAbort: func(err error) bool { return errors.Is(err, errFatal) }})
+	requests := []models.WeatherRequest{{ZipCode: "10001"}, {ZipCode: "90210"}, {ZipCode: "12601"}}
+
+	summary := p.Run(context.Background(), requests, func(ctx context.Context, req models.WeatherRequest) error {
+		return errFatal
+	})
+
+	want := []string{StatusFailed, StatusCancelled, StatusCancelled}
+	for i, result := range summary.Results {
+		if result.Request.ZipCode != requests[i].ZipCode {
+			t.Errorf("result %d is for %s, want %s", i, result.Request.ZipCode, requests[i].ZipCode)
+		}
+		if result.Status != want[i] {
+			t.Errorf("result %d status = %q, want %q", i, result.Status, want[i])
+		}
+	}
+	if summary.Total != 3 || summary.Failed != 1 || summary.Cancelled != 2 {
+		t.Errorf("summary = %+v, want 3 total, 1 failed, 2 cancelled", summary)
+	}
+}
+
+func TestPoolConcurrencyLimit(t *testing.T) {
+	const concurrency = 3
+
+	var mu sync.Mutex
+	active, peak := 0, 0
+
+	p := NewPool(Config{Concurrency: concurrency})
+	requests := make([]models.WeatherRequest, 12)
+	summary := p.Run(context.Background(), requests, func(ctx context.Context, req models.WeatherRequest) error {
+		mu.Lock()
+		active++
+		peak = max(peak, active)
+		mu.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+
+		mu.Lock()
+		active--
+		mu.Unlock()
+		return nil
+	})
+
+	if summary.Succeeded != len(requests) {
+		t.Errorf("succeeded = %d, want %d", summary.Succeeded, len(requests))
+	}
+	if peak > concurrency {
+		t.Errorf("peak concurrency = %d, want at most %d", peak, concurrency)
+	}
+}
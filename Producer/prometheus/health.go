@@ -0,0 +1,145 @@
+package prometheus
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Location and overall health statuses reported by /healthz
+const (
+	HealthOK        = "ok"        // Polled successfully within the stale period
+	HealthPending   = "pending"   // Not polled successfully yet, but added less than the stale period ago
+	HealthStale     = "stale"     // No successful poll within the stale period
+	HealthDegraded  = "degraded"  // Some locations are stale
+	HealthUnhealthy = "unhealthy" // Every location is stale
+)
+
+// PollHealth tracks the last poll of every location and serves it as JSON on /healthz.
+// The endpoint answers 503 only when every location is stale, so one bad location does not fail the whole producer.
+type PollHealth struct {
+	mu         sync.Mutex
+	staleAfter time.Duration
+	locations  map[string]*locationHealth
+	now        func() time.Time
+}
+
+// locationHealth is the poll state of one location, also used as its JSON report
+type locationHealth struct {
+	Status      string     `json:"status"`
+	LastSuccess *time.Time `json:"last_success,omitempty"`
+	LastAttempt *time.Time `json:"last_attempt,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+	added       time.Time
+}
+
+// healthReport is the /healthz response body
+type healthReport struct {
+	Status    string                    `json:"status"`
+	Locations map[string]locationHealth `json:"locations"`
+}
+
+// NewPollHealth creates a new PollHealth instance; a zero staleAfter never reports locations as stale
+func NewPollHealth(staleAfter time.Duration) *PollHealth {
+	return &PollHealth{
+		staleAfter: staleAfter,
+		locations:  make(map[string]*locationHealth),
+		now:        time.Now,
+	}
+}
+
+// SetLocations tracks the given locations, keeping the state of known ones, and returns the locations removed
+func (h *PollHealth) SetLocations(locationIDs []string) []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	keep := make(map[string]bool, len(locationIDs))
+	for _, locationID := range locationIDs {
+		keep[locationID] = true
+		h.location(locationID)
+	}
+
+	var removed []string
+	for locationID := range h.locations {
+		if !keep[locationID] {
+			delete(h.locations, locationID)
+			removed = append(removed, locationID)
+		}
+	}
+	return removed
+}
+
+// RecordPoll records the outcome of a current or forecast poll of a location
+func (h *PollHealth) RecordPoll(locationID, kind string, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	location := h.location(locationID)
+	now := h.now()
+	location.LastAttempt = &now
+	if err != nil {
+		location.LastError = kind + ": " + err.Error()
+		return
+	}
+	location.LastSuccess = &now
+	location.LastError = ""
+}
+
+// location returns the state of a location, tracking it if it is new; callers must hold h.mu
+func (h *PollHealth) location(locationID string) *locationHealth {
+	location, exists := h.locations[locationID]
+	if !exists {
+		location = &locationHealth{added: h.now()}
+		h.locations[locationID] = location
+	}
+	return location
+}
+
+// report returns the status of every location and the overall status
+func (h *PollHealth) report() healthReport {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	report := healthReport{
+		Status:    HealthOK,
+		Locations: make(map[string]locationHealth, len(h.locations)),
+	}
+
+	now := h.now()
+	stale := 0
+	for locationID, location := range h.locations {
+		entry := *location
+		switch {
+		case h.staleAfter <= 0 && entry.LastSuccess != nil:
+			entry.Status = HealthOK
+		case entry.LastSuccess != nil && now.Sub(*entry.LastSuccess) <= h.staleAfter:
+			entry.Status = HealthOK
+		case entry.LastSuccess == nil && (h.staleAfter <= 0 || now.Sub(entry.added) <= h.staleAfter):
+			entry.Status = HealthPending
+		default:
+			entry.Status = HealthStale
+			stale++
+		}
+		report.Locations[locationID] = entry
+	}
+
+	if stale > 0 {
+		report.Status = HealthDegraded
+		if stale == len(h.locations) {
+			report.Status = HealthUnhealthy
+		}
+	}
+	return report
+}
+
+// ServeHTTP writes the health report as JSON
+func (h *PollHealth) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	report := h.report()
+
+	w.Header().Set("Content-Type", "application/json")
+	if report.Status == HealthUnhealthy {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(report)
+}
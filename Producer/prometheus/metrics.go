@@ -0,0 +1,280 @@
+package prometheus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// QuotaSource reports weather API quota usage
+type QuotaSource interface {
+	Usage() (used, limit int)
+	Total() int
+}
+
+// DeliverySource reports Kafka messages awaiting acknowledgement
+type DeliverySource interface {
+	PendingDeliveries() int
+}
+
+// ProducerMetrics holds all Prometheus metrics for the weather producer, in a registry of its own
+type ProducerMetrics struct {
+	registry *prometheus.Registry
+	health   *PollHealth
+
+	// Quota metrics
+	quotaUsed      prometheus.GaugeFunc
+	quotaLimit     prometheus.GaugeFunc
+	quotaRemaining prometheus.GaugeFunc
+	apiCallsTotal  prometheus.CounterFunc
+
+	// Counter metrics
+	pollsDeferredTotal   *prometheus.CounterVec
+	pollsTotal           *prometheus.CounterVec
+	batchRequestsTotal   *prometheus.CounterVec
+	apiErrorsTotal       *prometheus.CounterVec
+	kafkaDeliveriesTotal *prometheus.CounterVec
+
+	// Gauge metrics
+	lastSuccessfulPoll *prometheus.GaugeVec
+
+	// Histogram metrics
+	apiRequestDuration *prometheus.HistogramVec
+}
+
+// NewProducerMetrics creates a new ProducerMetrics instance.
+// Locations without a successful poll for staleAfter are reported as stale by the health endpoint.
+func NewProducerMetrics(quota QuotaSource, staleAfter time.Duration) *ProducerMetrics {
+	metrics := &ProducerMetrics{
+		registry: prometheus.NewRegistry(),
+		health:   NewPollHealth(staleAfter),
+
+		// Quota gauges read live from the quota tracker
+		quotaUsed: prometheus.NewGaugeFunc(
+			prometheus.GaugeOpts{
+				Name: "weather_producer_api_quota_used",
+				Help: "Weather API calls made today (UTC)",
+			},
+			func() float64 {
+				used, _ := quota.Usage()
+				return float64(used)
+			},
+		),
+
+		quotaLimit: prometheus.NewGaugeFunc(
+			prometheus.GaugeOpts{
+				Name: "weather_producer_api_quota_limit",
+				Help: "Daily weather API call budget (0 = unlimited)",
+			},
+			func() float64 {
+				_, limit := quota.Usage()
+				return float64(limit)
+			},
+		),
+
+		quotaRemaining: prometheus.NewGaugeFunc(
+			prometheus.GaugeOpts{
+				Name: "weather_producer_api_quota_remaining",
+				Help: "Weather API calls left in today's budget",
+			},
+			func() float64 {
+				used, limit := quota.Usage()
+				if limit <= 0 || used > limit {
+					return 0
+				}
+				return float64(limit - used)
+			},
+		),
+
+		apiCallsTotal: prometheus.NewCounterFunc(
+			prometheus.CounterOpts{
+				Name: "weather_producer_api_calls_total",
+				Help: "Total number of weather API calls made since startup",
+			},
+			func() float64 {
+				return float64(quota.Total())
+			},
+		),
+
+		// Counter metrics
+		pollsDeferredTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "weather_producer_polls_deferred_total",
+				Help: "Total number of location polls deferred to preserve the daily quota",
+			},
+			[]string{"zip_code", "priority"},
+		),
+
+		pollsTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "weather_producer_polls_total",
+				Help: "Total number of location polls by kind (current/forecast) and status (success/failed)",
+			},
+			[]string{"zip_code", "kind", "status"},
+		),
+
+		batchRequestsTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "weather_producer_batch_requests_total",
+				Help: "Total number of locations processed in startup and reload batches by outcome",
+			},
+			[]string{"batch", "status"},
+		),
+
+		apiErrorsTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "weather_producer_api_errors_total",
+				Help: "Total number of failed weather API requests by endpoint and error class",
+			},
+			[]string{"endpoint", "class"},
+		),
+
+		kafkaDeliveriesTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "weather_producer_kafka_deliveries_total",
+				Help: "Total number of Kafka messages acknowledged (status=delivered) or given up on (status=failed)",
+			},
+			[]string{"message_type", "status"},
+		),
+
+		// Gauge metrics
+		lastSuccessfulPoll: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "weather_producer_last_successful_poll_timestamp_seconds",
+				Help: "Unix time of the last successful poll of each location",
+			},
+			[]string{"zip_code"},
+		),
+
+		// Histogram metrics
+		apiRequestDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "weather_producer_api_request_duration_seconds",
+				Help:    "Latency of weather API requests by endpoint, including failed ones",
+				Buckets: []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+			},
+			[]string{"endpoint"},
+		),
+	}
+
+	// Register all metrics, plus the Go runtime and process metrics the default registry would provide
+	metrics.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		metrics.quotaUsed,
+		metrics.quotaLimit,
+		metrics.quotaRemaining,
+		metrics.apiCallsTotal,
+		metrics.pollsDeferredTotal,
+		metrics.pollsTotal,
+		metrics.batchRequestsTotal,
+		metrics.apiErrorsTotal,
+		metrics.kafkaDeliveriesTotal,
+		metrics.lastSuccessfulPoll,
+		metrics.apiRequestDuration,
+	)
+
+	return metrics
+}
+
+// IncrementPollsDeferred increments the deferred polls counter
+func (pm *ProducerMetrics) IncrementPollsDeferred(zipCode, priority string) {
+	pm.pollsDeferredTotal.WithLabelValues(zipCode, priority).Inc()
+}
+
+// ObserveAPIRequest records the latency of a weather API request and, when errorClass is set, its failure
+func (pm *ProducerMetrics) ObserveAPIRequest(endpoint string, duration time.Duration, errorClass string) {
+	pm.apiRequestDuration.WithLabelValues(endpoint).Observe(duration.Seconds())
+	if errorClass != "" {
+		pm.apiErrorsTotal.WithLabelValues(endpoint, errorClass).Inc()
+	}
+}
+
+// RecordPoll counts the outcome of a current or forecast poll and updates the location's health
+func (pm *ProducerMetrics) RecordPoll(zipCode, kind string, err error) {
+	status := "success"
+	if err != nil {
+		status = "failed"
+	} else {
+		pm.lastSuccessfulPoll.WithLabelValues(zipCode).SetToCurrentTime()
+	}
+	pm.pollsTotal.WithLabelValues(zipCode, kind, status).Inc()
+	pm.health.RecordPoll(zipCode, kind, err)
+}
+
+// RecordBatch counts the outcome of every location in a startup or reload batch
+func (pm *ProducerMetrics) RecordBatch(batch string, succeeded, failed, deferred, cancelled int) {
+	pm.batchRequestsTotal.WithLabelValues(batch, "succeeded").Add(float64(succeeded))
+	pm.batchRequestsTotal.WithLabelValues(batch, "failed").Add(float64(failed))
+	pm.batchRequestsTotal.WithLabelValues(batch, "deferred").Add(float64(deferred))
+	pm.batchRequestsTotal.WithLabelValues(batch, "cancelled").Add(float64(cancelled))
+}
+
+// SetLocations sets the locations reported by the health endpoint; removed locations are forgotten
+func (pm *ProducerMetrics) SetLocations(zipCodes []string) {
+	for _, removed := range pm.health.SetLocations(zipCodes) {
+		pm.lastSuccessfulPoll.DeleteLabelValues(removed)
+	}
+}
+
+// RecordDelivery counts the delivery result of a Kafka message
+func (pm *ProducerMetrics) RecordDelivery(messageType string, err error) {
+	status := "delivered"
+	if err != nil {
+		status = "failed"
+	}
+	pm.kafkaDeliveriesTotal.WithLabelValues(messageType, status).Inc()
+}
+
+// ObserveDeliveries exposes the number of Kafka messages awaiting acknowledgement
+func (pm *ProducerMetrics) ObserveDeliveries(source DeliverySource) {
+	pm.registry.MustRegister(prometheus.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Name: "weather_producer_kafka_pending_messages",
+			Help: "Kafka messages handed to the writer and not yet acknowledged",
+		},
+		func() float64 {
+			return float64(source.PendingDeliveries())
+		},
+	))
+}
+
+// StartMetricsServer serves /metrics and /healthz until ctx is cancelled, then gives in-flight scrapes
+// up to shutdownTimeout to finish
+func (pm *ProducerMetrics) StartMetricsServer(ctx context.Context, port string, shutdownTimeout time.Duration) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(pm.registry, promhttp.HandlerOpts{Registry: pm.registry}))
+	mux.Handle("/healthz", pm.health)
+
+	server := &http.Server{
+		Addr:    ":" + port,
+		Handler: mux,
+	}
+
+	shutdownDone := make(chan error, 1)
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		shutdownDone <- server.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("📊 Starting Producer metrics server on port %s", port)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to start metrics server: %v", err)
+	}
+
+	if err := <-shutdownDone; err != nil {
+		return fmt.Errorf("metrics server did not shut down cleanly: %v", err)
+	}
+	log.Println("📊 Producer metrics server stopped")
+	return nil
+}
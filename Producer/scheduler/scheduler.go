@@ -0,0 +1,248 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"math/rand"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/abhijeet1999/weather/models"
+)
+
+// Task polls a single location for one kind of weather data
+type Task func(ctx context.Context, req models.WeatherRequest) error
+
+// Config holds the default polling settings for the scheduler
+type Config struct {
+	CurrentInterval  time.Duration // Default interval for current weather polls
+	ForecastInterval time.Duration // Default interval for forecast polls
+	Jitter           float64       // Random spread applied to each interval (0.1 = ±10%)
+}
+
+// Scheduler periodically re-polls every weather request
+type Scheduler struct {
+	config       Config
+	currentTask  Task
+	forecastTask Task
+
+	mu      sync.Mutex
+	jobs    map[string]*job
+	wg      sync.WaitGroup
+	ctx     context.Context
+	cancel  context.CancelFunc
+	stopped bool
+}
+
+// job represents a single recurring poll for one location
+type job struct {
+	name     string
+	kind     string
+	req      models.WeatherRequest
+	interval time.Duration
+	task     Task
+	running  *atomic.Bool       // Shared with the job this one replaces, so their runs never overlap
+	cancel   context.CancelFunc // Stops the job's loop and cancels its run when it is removed or replaced
+}
+
+// NewScheduler creates a new Scheduler instance
+func NewScheduler(config Config, currentTask, forecastTask Task) *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Scheduler{
+		config:       config,
+		currentTask:  currentTask,
+		forecastTask: forecastTask,
+		jobs:         make(map[string]*job),
+		ctx:          ctx,
+		cancel:       cancel,
+	}
+}
+
+// Start schedules current and forecast polls for every request
+func (s *Scheduler) Start(requests []models.WeatherRequest) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopped {
+		return
+	}
+
+	s.sync(requests)
+	log.Printf("⏰ Scheduler started with %d jobs for %d locations", len(s.jobs), len(requests))
+}
+
+// Reload replaces the scheduled locations: new locations are added, removed ones stopped,
+// and locations whose settings changed are rescheduled. Unchanged jobs keep their timers.
+// It returns the requests for locations that were not scheduled before.
+func (s *Scheduler) Reload(requests []models.WeatherRequest) []models.WeatherRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopped {
+		return nil
+	}
+
+	previous := make(map[string]bool, len(s.jobs))
+	for _, j := range s.jobs {
+		previous[j.req.LocationKey()] = true
+	}
+
+	added, changed, removed := s.sync(requests)
+
+	var newLocations []models.WeatherRequest
+	for _, req := range requests {
+		if !previous[req.LocationKey()] {
+			newLocations = append(newLocations, req)
+		}
+	}
+
+	log.Printf("⏰ Scheduler reloaded: %d jobs for %d locations (%d added, %d changed, %d removed)",
+		len(s.jobs), len(requests), added, changed, removed)
+	return newLocations
+}
+
+// sync reconciles the running jobs with the requests; callers must hold s.mu
+func (s *Scheduler) sync(requests []models.WeatherRequest) (added, changed, removed int) {
+	desired := make(map[string]*job)
+	for _, req := range requests {
+		currentInterval := req.CurrentInterval
+		if currentInterval <= 0 {
+			currentInterval = s.config.CurrentInterval
+		}
+		if j := s.newJob("current", req, currentInterval, s.currentTask); j != nil {
+			desired[j.name] = j
+		}
+
+		forecastInterval := req.ForecastInterval
+		if forecastInterval <= 0 {
+			forecastInterval = s.config.ForecastInterval
+		}
+		if j := s.newJob("forecast", req, forecastInterval, s.forecastTask); j != nil {
+			desired[j.name] = j
+		}
+	}
+
+	for name, existing := range s.jobs {
+		if _, keep := desired[name]; !keep {
+			existing.cancel()
+			delete(s.jobs, name)
+			removed++
+		}
+	}
+
+	for name, j := range desired {
+		existing, exists := s.jobs[name]
+		if exists && existing.interval == j.interval && reflect.DeepEqual(existing.req, j.req) {
+			continue
+		}
+
+		if exists {
+			existing.cancel()
+			j.running = existing.running
+			changed++
+		} else {
+			added++
+		}
+		s.startJob(j)
+	}
+
+	return added, changed, removed
+}
+
+// newJob builds a recurring job, or returns nil when the kind of poll is disabled
+func (s *Scheduler) newJob(kind string, req models.WeatherRequest, interval time.Duration, task Task) *job {
+	if task == nil || interval <= 0 {
+		return nil
+	}
+
+	return &job{
+		name:     kind + ":" + req.LocationKey(),
+		kind:     kind,
+		req:      req,
+		interval: interval,
+		task:     task,
+		running:  new(atomic.Bool),
+	}
+}
+
+// startJob registers and launches a recurring job; callers must hold s.mu
+func (s *Scheduler) startJob(j *job) {
+	ctx, cancel := context.WithCancel(s.ctx)
+	j.cancel = cancel
+	s.jobs[j.name] = j
+
+	s.wg.Add(1)
+	go s.loop(ctx, j)
+}
+
+// loop waits for each tick of a job and dispatches a run
+func (s *Scheduler) loop(ctx context.Context, j *job) {
+	defer s.wg.Done()
+
+	timer := time.NewTimer(s.nextDelay(j.interval))
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+			s.dispatch(ctx, j)
+			timer.Reset(s.nextDelay(j.interval))
+		}
+	}
+}
+
+// dispatch runs a job in the background unless its previous run, or that of the job it replaced, is still in progress.
+// The run gets the job's context, so removing or replacing the job cancels it.
+func (s *Scheduler) dispatch(ctx context.Context, j *job) {
+	if !j.running.CompareAndSwap(false, true) {
+		log.Printf("⏭️ Skipping %s poll for %s: previous run still in progress", j.kind, j.req.LocationID())
+		return
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer j.running.Store(false)
+
+		start := time.Now()
+		if err := j.task(ctx, j.req); err != nil {
+			log.Printf("⚠️ Scheduled %s poll for %s did not complete: %v", j.kind, j.req.LocationID(), err)
+			return
+		}
+		log.Printf("✅ Scheduled %s poll completed for %s in %s", j.kind, j.req.LocationID(), time.Since(start).Round(time.Millisecond))
+	}()
+}
+
+// nextDelay returns the interval with random jitter applied
+func (s *Scheduler) nextDelay(interval time.Duration) time.Duration {
+	if s.config.Jitter <= 0 {
+		return interval
+	}
+
+	spread := float64(interval) * s.config.Jitter
+	delay := time.Duration(float64(interval) + (rand.Float64()*2-1)*spread)
+	if delay < time.Second {
+		delay = time.Second
+	}
+	return delay
+}
+
+// Stop cancels all scheduled jobs and waits for in-flight runs to finish
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	if s.stopped {
+		s.mu.Unlock()
+		return
+	}
+	s.stopped = true
+	s.cancel()
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	log.Println("⏰ Scheduler stopped")
+}
@@ -0,0 +1,209 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/abhijeet1999/weather/models"
+)
+
+// pollLog records the polls a task receives
+type pollLog struct {
+	mu      sync.Mutex
+	polls   map[string]int
+	active  int
+	overlap int // Highest number of runs in progress at once
+}
+
+func newPollLog() *pollLog {
+	return &pollLog{polls: make(map[string]int)}
+}
+
+// task returns a Task that records each poll and then blocks for hold or until its context is cancelled
+func (p *pollLog) task(hold time.Duration) Task {
+	return func(ctx context.Context, req models.WeatherRequest) error {
+		p.mu.Lock()
+		p.polls[req.ZipCode]++
+		p.active++
+		p.overlap = max(p.overlap, p.active)
+		p.mu.Unlock()
+
+		defer func() {
+			p.mu.Lock()
+			p.active--
+			p.mu.Unlock()
+		}()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(hold):
+			return nil
+		}
+	}
+}
+
+func (p *pollLog) count(zipCode string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.polls[zipCode]
+}
+
+// waitFor polls cond until it holds or the deadline passes
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met within 2s")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSchedulerPollsEveryLocation(t *testing.T) {
+	tests := []struct {
+		name             string
+		config           Config
+		req              models.WeatherRequest
+		wantCurrentPolls bool
+		wantForecastPoll bool
+	}{
+		{
+			name:             "defaults",
+			config:           Config{CurrentInterval: 5 * time.Millisecond, ForecastInterval: 5 * time.Millisecond},
+			req:              models.WeatherRequest{ZipCode: "10001"},
+			wantCurrentPolls: true,
+			wantForecastPoll: true,
+		},
+		{
+			name:             "forecast polls disabled",
+			config:           Config{CurrentInterval: 5 * time.Millisecond},
+			req:              models.WeatherRequest{ZipCode: "10001"},
+			wantCurrentPolls: true,
+		},
+		{
+			name:             "per-location interval overrides the default",
+			config:           Config{CurrentInterval: time.Hour, ForecastInterval: time.Hour},
+			req:              models.WeatherRequest{ZipCode: "10001", ForecastInterval: 5 * time.Millisecond},
+			wantForecastPoll: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current, forecast := newPollLog(), newPollLog()
+			s := NewScheduler(tt.config, current.task(0), forecast.task(0))
+			s.Start([]models.WeatherRequest{tt.req})
+
+			// Wait for a few polls of whichever kind is expected, then make sure the other never ran
+			waitFor(t, func() bool {
+				return (!tt.wantCurrentPolls || current.count(tt.req.ZipCode) >= 3) &&
+					(!tt.wantForecastPoll || forecast.count(tt.req.ZipCode) >= 3)
+			})
+			s.Stop()
+
+			if !tt.wantCurrentPolls && current.count(tt.req.ZipCode) > 0 {
+				t.Errorf("current polls = %d, want none", current.count(tt.req.ZipCode))
+			}
+			if !tt.wantForecastPoll && forecast.count(tt.req.ZipCode) > 0 {
+				t.Errorf("forecast polls = %d, want none", forecast.count(tt.req.ZipCode))
+			}
+		})
+	}
+}
+
+func TestSchedulerSkipsOverlappingRuns(t *testing.T) {
+	polls := newPollLog()
+	s := NewScheduler(Config{CurrentInterval: time.Millisecond}, polls.task(time.Hour), nil)
+	s.Start([]models.WeatherRequest{{ZipCode: "10001"}})
+
+	// The first run blocks, so every later tick must be skipped
+	waitFor(t, func() bool { return polls.count("10001") == 1 })
+	time.Sleep(20 * time.Millisecond)
+	got := polls.count("10001")
+	s.Stop()
+
+	if got != 1 {
+		t.Errorf("polls = %d, want 1 while the first run is in progress", got)
+	}
+	if polls.overlap != 1 {
+		t.Errorf("overlapping runs = %d, want 1", polls.overlap)
+	}
+}
+
+func TestSchedulerStopCancelsRunsAndWaits(t *testing.T) {
+	polls := newPollLog()
+	s := NewScheduler(Config{CurrentInterval: time.Millisecond}, polls.task(time.Hour), nil)
+	s.Start([]models.WeatherRequest{{ZipCode: "10001"}})
+	waitFor(t, func() bool { return polls.count("10001") == 1 })
+
+	done := make(chan struct{})
+	go func() {
+		s.Stop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Stop did not cancel the in-flight run")
+	}
+
+	polls.mu.Lock()
+	defer polls.mu.Unlock()
+	if polls.active != 0 {
+		t.Errorf("runs still active after Stop: %d", polls.active)
+	}
+}
+
+func TestSchedulerReload(t *testing.T) {
+	nyc := models.WeatherRequest{ZipCode: "10001", Days: 1}
+	la := models.WeatherRequest{ZipCode: "90210", Days: 1}
+	s := NewScheduler(Config{CurrentInterval: time.Hour}, newPollLog().task(0), nil)
+	defer s.Stop()
+	s.Start([]models.WeatherRequest{nyc})
+	unchanged := s.jobs["current:"+nyc.LocationKey()]
+
+	added := s.Reload([]models.WeatherRequest{nyc, la})
+	if len(added) != 1 || added[0].ZipCode != la.ZipCode {
+		t.Errorf("Reload() new locations = %+v, want only %s", added, la.ZipCode)
+	}
+	if s.jobs["current:"+nyc.LocationKey()] != unchanged {
+		t.Error("unchanged location was rescheduled")
+	}
+
+	changed := nyc
+	changed.Days = 3
+	if added := s.Reload([]models.WeatherRequest{changed}); len(added) != 0 {
+		t.Errorf("Reload() new locations = %+v, want none for a changed location", added)
+	}
+	if s.jobs["current:"+nyc.LocationKey()] == unchanged {
+		t.Error("changed location was not rescheduled")
+	}
+	if _, exists := s.jobs["current:"+la.LocationKey()]; exists || len(s.jobs) != 1 {
+		t.Errorf("jobs after removing %s = %d, want 1", la.ZipCode, len(s.jobs))
+	}
+}
+
+func TestSchedulerReloadCancelsReplacedRun(t *testing.T) {
+	polls := newPollLog()
+	req := models.WeatherRequest{ZipCode: "10001", Days: 1}
+	s := NewScheduler(Config{CurrentInterval: time.Millisecond}, polls.task(time.Hour), nil)
+	defer s.Stop()
+	s.Start([]models.WeatherRequest{req})
+	waitFor(t, func() bool { return polls.count("10001") == 1 })
+
+	// The replaced job's run is cancelled, and the replacement only starts once it has returned
+	req.Days = 2
+	s.Reload([]models.WeatherRequest{req})
+	waitFor(t, func() bool { return polls.count("10001") >= 2 })
+
+	polls.mu.Lock()
+	defer polls.mu.Unlock()
+	if polls.overlap != 1 {
+		t.Errorf("overlapping runs = %d, want 1", polls.overlap)
+	}
+}
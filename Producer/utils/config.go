@@ -0,0 +1,663 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abhijeet1999/weather/models"
+	"gopkg.in/yaml.v3"
+)
+
+// Keys accepted in each section of the structured config file
+var (
+	configRootKeys       = []string{"defaults", "locations"}
+	configDefaultsKeys   = []string{"country", "days", "units", "provider", "priority", "poll", "thresholds", "tags", "notify"}
+	configLocationKeys   = []string{"id", "name", "zip", "city", "coordinates", "country", "days", "units", "provider", "priority", "poll", "thresholds", "tags", "notify"}
+	configCoordinateKeys = []string{"lat", "lon"}
+	configPollKeys       = []string{"current", "forecast"}
+	configThresholdKeys  = []string{"units", "temp", "wind", "humidity"}
+	configNotifyKeys     = []string{"type", "target"}
+)
+
+// locationIDPattern restricts IDs to characters that are safe in message keys and metric labels
+var locationIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._:-]*$`)
+
+// ConfigError points to the offending key in a config file
+type ConfigError struct {
+	Path    string // Key path, e.g. "locations[2].thresholds.temp"
+	Line    int
+	Message string
+}
+
+// Error implements the error interface
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("line %d: %s: %s", e.Line, e.Path, e.Message)
+}
+
+// IsConfigFile reports whether a locations file uses the structured YAML/JSON format
+func IsConfigFile(filename string) bool {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".yaml", ".yml", ".json":
+		return true
+	default:
+		return false
+	}
+}
+
+// LoadLocations reads weather requests from a YAML/JSON config file or a legacy input.txt file
+func LoadLocations(filename string) ([]models.WeatherRequest, error) {
+	if IsConfigFile(filename) {
+		return LoadConfigFile(filename)
+	}
+	return ParseInputFile(filename)
+}
+
+// LoadConfigFile reads and validates a YAML or JSON locations config file
+func LoadConfigFile(filename string) ([]models.WeatherRequest, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open config file: %w", err)
+	}
+
+	requests, err := ParseConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config file %s:\n%w", filename, err)
+	}
+	return requests, nil
+}
+
+// parseLocations parses already-read locations file content in the format implied by the file name
+func parseLocations(filename string, data []byte) ([]models.WeatherRequest, error) {
+	if !IsConfigFile(filename) {
+		return parseInput(bytes.NewReader(data))
+	}
+
+	requests, err := ParseConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config file %s:\n%w", filename, err)
+	}
+	return requests, nil
+}
+
+// ParseConfig parses a YAML or JSON locations document.
+// Every schema violation is reported as a ConfigError, joined into a single error.
+func ParseConfig(data []byte) ([]models.WeatherRequest, error) {
+	// JSON is a subset of YAML, so one parser handles both formats
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, fmt.Errorf("config is empty")
+	}
+
+	d := &configDecoder{}
+	requests := d.document(doc.Content[0])
+	if len(d.errs) > 0 {
+		sort.SliceStable(d.errs, func(i, j int) bool {
+			return d.errs[i].(*ConfigError).Line < d.errs[j].(*ConfigError).Line
+		})
+		return nil, errors.Join(d.errs...)
+	}
+	if len(requests) == 0 {
+		return nil, fmt.Errorf("no locations defined in config")
+	}
+
+	return requests, nil
+}
+
+// configDecoder walks a parsed config document and collects errors that point to the offending key
+type configDecoder struct {
+	errs []error
+}
+
+// locationBuilder tracks which required settings have been given for a location
+type locationBuilder struct {
+	req         models.WeatherRequest
+	hasDays     bool
+	hasTemp     bool
+	hasWind     bool
+	hasHumidity bool
+}
+
+// document decodes the root mapping
+func (d *configDecoder) document(root *yaml.Node) []models.WeatherRequest {
+	fields := d.mapping(root, "", configRootKeys)
+	if fields == nil {
+		return nil
+	}
+
+	defaults := locationBuilder{}
+	if node, exists := fields["defaults"]; exists {
+		d.settings(d.mapping(node, "defaults", configDefaultsKeys), "defaults", &defaults)
+	}
+
+	node, exists := fields["locations"]
+	if !exists {
+		d.fail(root, "locations", "missing required key")
+		return nil
+	}
+	node = resolve(node)
+	if node.Kind != yaml.SequenceNode {
+		d.fail(node, "locations", "expected a list of locations, got %s", kindName(node))
+		return nil
+	}
+
+	var requests []models.WeatherRequest
+	seen := make(map[string]string)
+	for i, item := range node.Content {
+		path := fmt.Sprintf("locations[%d]", i)
+		req, ok := d.location(item, path, defaults)
+		if !ok {
+			continue
+		}
+
+		id := req.LocationID()
+		if previous, exists := seen[id]; exists {
+			d.fail(item, path, "duplicate location '%s' (already defined by %s)", id, previous)
+			continue
+		}
+		seen[id] = path
+
+		requests = append(requests, req)
+	}
+
+	return requests
+}
+
+// location decodes a single location entry on top of the defaults
+func (d *configDecoder) location(node *yaml.Node, path string, defaults locationBuilder) (models.WeatherRequest, bool) {
+	errCount := len(d.errs)
+	fields := d.mapping(node, path, configLocationKeys)
+	if fields == nil {
+		return models.WeatherRequest{}, false
+	}
+
+	b := defaults
+	b.req.Tags = append([]string(nil), defaults.req.Tags...)
+	b.req.Notify = append([]models.NotificationTarget(nil), defaults.req.Notify...)
+
+	if value, ok := d.optionalString(fields, path, "id"); ok {
+		if !locationIDPattern.MatchString(value) {
+			d.fail(fields["id"], joinPath(path, "id"), "'%s' must start with a letter or digit and contain only letters, digits, '.', '_', ':' or '-'", value)
+		}
+		b.req.ID = value
+	}
+	if value, ok := d.optionalString(fields, path, "name"); ok {
+		b.req.Name = value
+	}
+
+	d.settings(fields, path, &b)
+	d.place(node, fields, path, &b)
+
+	for _, required := range []struct {
+		key string
+		set bool
+	}{
+		{"days", b.hasDays},
+		{"thresholds.temp", b.hasTemp},
+		{"thresholds.wind", b.hasWind},
+		{"thresholds.humidity", b.hasHumidity},
+	} {
+		if !required.set {
+			d.fail(node, joinPath(path, required.key), "missing required key (set it on the location or in defaults)")
+		}
+	}
+
+	return b.req, len(d.errs) == errCount
+}
+
+// place decodes exactly one of zip, city or coordinates
+func (d *configDecoder) place(node *yaml.Node, fields map[string]*yaml.Node, path string, b *locationBuilder) {
+	var given []string
+	for _, key := range []string{"zip", "city", "coordinates"} {
+		if _, exists := fields[key]; exists {
+			given = append(given, key)
+		}
+	}
+	if len(given) != 1 {
+		d.fail(node, path, "exactly one of zip, city or coordinates is required, got %d", len(given))
+		return
+	}
+
+	keyPath := joinPath(path, given[0])
+	switch given[0] {
+	case "zip":
+		value, ok := d.scalar(fields["zip"], keyPath)
+		if !ok {
+			return
+		}
+		country := b.req.Country
+		if country == "" {
+			country = DefaultCountry
+		}
+		zipCode, err := ValidatePostalCode(value, country)
+		if err != nil {
+			d.fail(fields["zip"], keyPath, "%v", err)
+			return
+		}
+		b.req.ZipCode = zipCode
+		b.req.Country = country
+
+	case "city":
+		value, ok := d.scalar(fields["city"], keyPath)
+		if !ok {
+			return
+		}
+		parts := strings.Split(value, ",")
+		for i := range parts {
+			parts[i] = strings.TrimSpace(parts[i])
+		}
+		if len(parts) == 1 && b.req.Country != "" {
+			parts = append(parts, b.req.Country)
+		}
+		if len(parts) == 1 {
+			d.fail(fields["city"], keyPath, "'%s' needs a country, as \"City,Country\", \"City,State,Country\" or a country key", value)
+			return
+		}
+		location, err := parseCityName(parts)
+		if err != nil {
+			d.fail(fields["city"], keyPath, "%v", err)
+			return
+		}
+		// The country in the city name wins over defaults.country; the location's own country key must agree with it
+		if _, exists := fields["country"]; exists && b.req.Country != location.Country {
+			d.fail(fields["country"], joinPath(path, "country"), "'%s' conflicts with country '%s' of city '%s'", b.req.Country, location.Country, value)
+			return
+		}
+		b.req.City = location.City
+		b.req.Country = location.Country
+
+	case "coordinates":
+		coordinates := d.mapping(fields["coordinates"], keyPath, configCoordinateKeys)
+		if coordinates == nil {
+			return
+		}
+		lat, latOK := d.requiredScalar(fields["coordinates"], coordinates, keyPath, "lat")
+		lon, lonOK := d.requiredScalar(fields["coordinates"], coordinates, keyPath, "lon")
+		if !latOK || !lonOK {
+			return
+		}
+		location, err := parseCoordinates(lat, lon)
+		if err != nil {
+			d.fail(fields["coordinates"], keyPath, "%v", err)
+			return
+		}
+		b.req.Lat = location.Lat
+		b.req.Lon = location.Lon
+		b.req.HasCoordinates = true
+	}
+}
+
+// settings decodes the keys shared by defaults and locations
+func (d *configDecoder) settings(fields map[string]*yaml.Node, path string, b *locationBuilder) {
+	if fields == nil {
+		return
+	}
+
+	if value, ok := d.optionalString(fields, path, "country"); ok {
+		country, err := NormalizeCountry(value)
+		if err != nil {
+			d.fail(fields["country"], joinPath(path, "country"), "%v", err)
+		}
+		b.req.Country = country
+	}
+
+	if node, exists := fields["days"]; exists {
+		b.hasDays = true
+		if days, ok := d.integer(node, joinPath(path, "days")); ok {
+			if err := validateDays(days); err != nil {
+				d.fail(node, joinPath(path, "days"), "%v", err)
+			}
+			b.req.Days = days
+		}
+	}
+
+	if value, ok := d.optionalString(fields, path, "units"); ok {
+		if !IsValidUnits(value) {
+			d.fail(fields["units"], joinPath(path, "units"), "'%s' must be %s or %s", value, UnitMetric, UnitImperial)
+		}
+		b.req.Units = value
+	}
+
+	if value, ok := d.optionalString(fields, path, "provider"); ok {
+		b.req.Provider = strings.ToLower(value)
+	}
+
+	if value, ok := d.optionalString(fields, path, "priority"); ok {
+		switch value {
+		case models.PriorityHigh, models.PriorityNormal, models.PriorityLow:
+		default:
+			d.fail(fields["priority"], joinPath(path, "priority"), "'%s' must be %s, %s or %s",
+				value, models.PriorityHigh, models.PriorityNormal, models.PriorityLow)
+		}
+		b.req.Priority = value
+	}
+
+	if node, exists := fields["poll"]; exists {
+		pollPath := joinPath(path, "poll")
+		if poll := d.mapping(node, pollPath, configPollKeys); poll != nil {
+			if value, ok := d.optionalDuration(poll, pollPath, "current"); ok {
+				b.req.CurrentInterval = value
+			}
+			if value, ok := d.optionalDuration(poll, pollPath, "forecast"); ok {
+				b.req.ForecastInterval = value
+			}
+		}
+	}
+
+	if node, exists := fields["thresholds"]; exists {
+		d.thresholds(node, joinPath(path, "thresholds"), b)
+	}
+
+	if node, exists := fields["tags"]; exists {
+		if tags, ok := d.stringList(node, joinPath(path, "tags")); ok {
+			b.req.Tags = append(b.req.Tags, tags...)
+		}
+	}
+
+	if node, exists := fields["notify"]; exists {
+		b.req.Notify = append(b.req.Notify, d.notify(node, joinPath(path, "notify"))...)
+	}
+}
+
+// thresholds decodes the alert thresholds of a location, converting them to °C and m/s.
+// Values are read in the thresholds' own units key, else the location's units.
+func (d *configDecoder) thresholds(node *yaml.Node, path string, b *locationBuilder) {
+	fields := d.mapping(node, path, configThresholdKeys)
+	if fields == nil {
+		return
+	}
+
+	units := NormalizeUnits(b.req.Units)
+	if value, ok := d.optionalString(fields, path, "units"); ok {
+		if !IsValidUnits(value) {
+			d.fail(fields["units"], joinPath(path, "units"), "'%s' must be %s or %s", value, UnitMetric, UnitImperial)
+		}
+		units = NormalizeUnits(value)
+	}
+
+	if node, exists := fields["temp"]; exists {
+		b.hasTemp = true
+		if value, ok := d.number(node, joinPath(path, "temp")); ok {
+			celsius := ToCelsius(float32(value), units)
+			if err := validateTempThreshold(float64(celsius)); err != nil {
+				d.fail(node, joinPath(path, "temp"), "%v%s", err, givenIn(value, TemperatureSymbol(units), units))
+			}
+			b.req.AlertTemp = celsius
+		}
+	}
+
+	if node, exists := fields["wind"]; exists {
+		b.hasWind = true
+		if value, ok := d.number(node, joinPath(path, "wind")); ok {
+			metersPerSecond := ToMetersPerSecond(float32(value), units)
+			if err := validateWindThreshold(float64(metersPerSecond)); err != nil {
+				d.fail(node, joinPath(path, "wind"), "%v%s", err, givenIn(value, WindSpeedSymbol(units), units))
+			}
+			b.req.AlertWind = metersPerSecond
+		}
+	}
+
+	if node, exists := fields["humidity"]; exists {
+		b.hasHumidity = true
+		if value, ok := d.integer(node, joinPath(path, "humidity")); ok {
+			if err := validateHumidityThreshold(value); err != nil {
+				d.fail(node, joinPath(path, "humidity"), "%v", err)
+			}
+			b.req.AlertHumidity = value
+		}
+	}
+}
+
+// notify decodes a list of notification targets
+func (d *configDecoder) notify(node *yaml.Node, path string) []models.NotificationTarget {
+	node = resolve(node)
+	if node.Kind != yaml.SequenceNode {
+		d.fail(node, path, "expected a list of notification targets, got %s", kindName(node))
+		return nil
+	}
+
+	var targets []models.NotificationTarget
+	for i, item := range node.Content {
+		itemPath := fmt.Sprintf("%s[%d]", path, i)
+		fields := d.mapping(item, itemPath, configNotifyKeys)
+		if fields == nil {
+			continue
+		}
+
+		kind, kindOK := d.requiredScalar(item, fields, itemPath, "type")
+		target, targetOK := d.requiredScalar(item, fields, itemPath, "target")
+		if !kindOK || !targetOK {
+			continue
+		}
+
+		targetPath := joinPath(itemPath, "target")
+		switch kind {
+		case models.NotifyWebhook:
+			if u, err := url.Parse(target); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				d.fail(fields["target"], targetPath, "'%s' is not an http(s) URL", target)
+				continue
+			}
+		case models.NotifyEmail:
+			if !strings.Contains(target, "@") {
+				d.fail(fields["target"], targetPath, "'%s' is not an email address", target)
+				continue
+			}
+		case models.NotifySlack:
+			if !strings.HasPrefix(target, "#") {
+				d.fail(fields["target"], targetPath, "'%s' is not a Slack channel (expected #channel)", target)
+				continue
+			}
+		default:
+			d.fail(fields["type"], joinPath(itemPath, "type"), "'%s' must be %s, %s or %s",
+				kind, models.NotifyWebhook, models.NotifyEmail, models.NotifySlack)
+			continue
+		}
+
+		targets = append(targets, models.NotificationTarget{Type: kind, Target: target})
+	}
+
+	return targets
+}
+
+// givenIn describes the original value of a converted threshold for error messages
+func givenIn(value float64, symbol, units string) string {
+	if units == UnitMetric {
+		return ""
+	}
+	return fmt.Sprintf(" (%.1f%s given)", value, symbol)
+}
+
+// mapping returns the values of a mapping node by key, reporting unknown and duplicate keys
+func (d *configDecoder) mapping(node *yaml.Node, path string, allowed []string) map[string]*yaml.Node {
+	node = resolve(node)
+	if node.Kind != yaml.MappingNode {
+		d.fail(node, pathOrRoot(path), "expected a mapping, got %s", kindName(node))
+		return nil
+	}
+
+	fields := make(map[string]*yaml.Node)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		keyPath := joinPath(path, key.Value)
+
+		if !containsString(allowed, key.Value) {
+			d.fail(key, keyPath, "unknown key (expected one of: %s)", strings.Join(allowed, ", "))
+			continue
+		}
+		if _, exists := fields[key.Value]; exists {
+			d.fail(key, keyPath, "duplicate key")
+			continue
+		}
+		fields[key.Value] = value
+	}
+
+	return fields
+}
+
+// scalar returns the text of a non-empty scalar value
+func (d *configDecoder) scalar(node *yaml.Node, path string) (string, bool) {
+	node = resolve(node)
+	if node.Kind != yaml.ScalarNode || node.Tag == "!!null" {
+		d.fail(node, path, "expected a value, got %s", kindName(node))
+		return "", false
+	}
+
+	value := strings.TrimSpace(node.Value)
+	if value == "" {
+		d.fail(node, path, "must not be empty")
+		return "", false
+	}
+	return value, true
+}
+
+// optionalString returns the scalar value of key when present
+func (d *configDecoder) optionalString(fields map[string]*yaml.Node, path, key string) (string, bool) {
+	node, exists := fields[key]
+	if !exists {
+		return "", false
+	}
+	return d.scalar(node, joinPath(path, key))
+}
+
+// requiredScalar returns the scalar value of key, reporting it when missing
+func (d *configDecoder) requiredScalar(parent *yaml.Node, fields map[string]*yaml.Node, path, key string) (string, bool) {
+	node, exists := fields[key]
+	if !exists {
+		d.fail(parent, joinPath(path, key), "missing required key")
+		return "", false
+	}
+	return d.scalar(node, joinPath(path, key))
+}
+
+// number returns a numeric scalar value
+func (d *configDecoder) number(node *yaml.Node, path string) (float64, bool) {
+	value, ok := d.scalar(node, path)
+	if !ok {
+		return 0, false
+	}
+
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		d.fail(node, path, "'%s' must be a number", value)
+		return 0, false
+	}
+	return number, true
+}
+
+// integer returns a whole-number scalar value
+func (d *configDecoder) integer(node *yaml.Node, path string) (int, bool) {
+	value, ok := d.scalar(node, path)
+	if !ok {
+		return 0, false
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		d.fail(node, path, "'%s' must be a whole number", value)
+		return 0, false
+	}
+	return number, true
+}
+
+// optionalDuration returns the positive duration value of key when present
+func (d *configDecoder) optionalDuration(fields map[string]*yaml.Node, path, key string) (time.Duration, bool) {
+	value, ok := d.optionalString(fields, path, key)
+	if !ok {
+		return 0, false
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		d.fail(fields[key], joinPath(path, key), "'%s' must be a positive duration such as 10m or 3h", value)
+		return 0, false
+	}
+	return duration, true
+}
+
+// stringList returns a list of scalar values
+func (d *configDecoder) stringList(node *yaml.Node, path string) ([]string, bool) {
+	node = resolve(node)
+	if node.Kind != yaml.SequenceNode {
+		d.fail(node, path, "expected a list, got %s", kindName(node))
+		return nil, false
+	}
+
+	values := make([]string, 0, len(node.Content))
+	for i, item := range node.Content {
+		if value, ok := d.scalar(item, fmt.Sprintf("%s[%d]", path, i)); ok {
+			values = append(values, value)
+		}
+	}
+	return values, len(values) == len(node.Content)
+}
+
+// fail records a validation error at a node
+func (d *configDecoder) fail(node *yaml.Node, path, format string, args ...interface{}) {
+	d.errs = append(d.errs, &ConfigError{
+		Path:    path,
+		Line:    node.Line,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// resolve follows YAML aliases to the anchored node
+func resolve(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	return node
+}
+
+// kindName describes a node type for error messages
+func kindName(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "a mapping"
+	case yaml.SequenceNode:
+		return "a list"
+	case yaml.ScalarNode:
+		if node.Tag == "!!null" {
+			return "null"
+		}
+		return fmt.Sprintf("'%s'", node.Value)
+	default:
+		return "an unsupported value"
+	}
+}
+
+// joinPath appends a key to a dotted key path
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// pathOrRoot names the document root in error messages
+func pathOrRoot(path string) string {
+	if path == "" {
+		return "(root)"
+	}
+	return path
+}
+
+// containsString reports whether values contains value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,177 @@
+package utils
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abhijeet1999/weather/models"
+)
+
+func TestParseConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		want    []models.WeatherRequest
+		wantErr []string
+	}{
+		{
+			name: "YAML locations inherit defaults",
+			config: `
+defaults:
+  days: 3
+  thresholds: {temp: 30, wind: 15, humidity: 85}
+  tags: [office]
+locations:
+  - id: nyc
+    zip: "10001"
+    poll: {current: 5m}
+    tags: [hq]
+  - city: Berlin,DE
+    days: 2
+    units: imperial
+    priority: high
+    notify:
+      - {type: slack, target: "#weather"}
+  - coordinates: {lat: 27.9506, lon: -90.1234}
+`,
+			want: []models.WeatherRequest{
+				{ID: "nyc", ZipCode: "10001", Country: "US", Days: 3, AlertTemp: 30, AlertWind: 15, AlertHumidity: 85,
+					CurrentInterval: 5 * time.Minute, Tags: []string{"office", "hq"}},
+				{City: "Berlin,DE", Country: "DE", Days: 2, AlertTemp: 30, AlertWind: 15, AlertHumidity: 85,
+					Units: UnitImperial, Priority: models.PriorityHigh, Tags: []string{"office"},
+					Notify: []models.NotificationTarget{{Type: models.NotifySlack, Target: "#weather"}}},
+				{Lat: 27.9506, Lon: -90.1234, HasCoordinates: true, Days: 3, AlertTemp: 30, AlertWind: 15, AlertHumidity: 85,
+					Tags: []string{"office"}},
+			},
+		},
+		{
+			name:   "JSON document",
+			config: `{"locations": [{"zip": "10115", "country": "de", "days": 1, "thresholds": {"temp": 25, "wind": 10, "humidity": 90}}]}`,
+			want: []models.WeatherRequest{
+				{ZipCode: "10115", Country: "DE", Days: 1, AlertTemp: 25, AlertWind: 10, AlertHumidity: 90},
+			},
+		},
+		{
+			name: "country in a city name wins over defaults.country",
+			config: `
+defaults: {country: US, days: 1, thresholds: {temp: 30, wind: 15, humidity: 85}}
+locations:
+  - city: Toronto,ON,CA
+  - city: Boston
+`,
+			want: []models.WeatherRequest{
+				{City: "Toronto,ON,CA", Country: "CA", Days: 1, AlertTemp: 30, AlertWind: 15, AlertHumidity: 85},
+				{City: "Boston,US", Country: "US", Days: 1, AlertTemp: 30, AlertWind: 15, AlertHumidity: 85},
+			},
+		},
+		{
+			name: "city conflicting with the location's country",
+			config: `
+defaults: {days: 1, thresholds: {temp: 30, wind: 15, humidity: 85}}
+locations:
+  - city: Toronto,ON,CA
+    country: US
+`,
+			wantErr: []string{"line 5: locations[0].country: 'US' conflicts with country 'CA' of city 'Toronto,ON,CA'"},
+		},
+		{
+			name: "unknown key",
+			config: `
+locations:
+  - zip: "10001"
+    days: 1
+    thresholds: {temp: 30, wind: 15, humidity: 85, rain: 5}
+`,
+			wantErr: []string{"line 5: locations[0].thresholds.rain: unknown key"},
+		},
+		{
+			name: "every error is reported",
+			config: `
+locations:
+  - zip: "10001"
+    days: 9
+    thresholds: {temp: 30, wind: 15}
+  - zip: "10001"
+    city: Boston,US
+    days: 1
+    thresholds: {temp: 30, wind: 15, humidity: 85}
+`,
+			wantErr: []string{
+				"line 3: locations[0].thresholds.humidity: missing required key",
+				"line 4: locations[0].days: invalid days value 9",
+				"line 6: locations[1]: exactly one of zip, city or coordinates is required, got 2",
+			},
+		},
+		{
+			name: "duplicate location",
+			config: `
+defaults: {days: 1, thresholds: {temp: 30, wind: 15, humidity: 85}}
+locations:
+  - zip: "10001"
+  - zip: "10001"
+`,
+			wantErr: []string{"line 5: locations[1]: duplicate location '10001' (already defined by locations[0])"},
+		},
+		{
+			name: "invalid values",
+			config: `
+defaults: {days: 1, thresholds: {temp: 30, wind: 15, humidity: 85}}
+locations:
+  - id: "-nyc"
+    zip: "10001"
+  - coordinates: {lat: 95, lon: 0}
+  - zip: "90210"
+    poll: {forecast: soon}
+    notify: [{type: webhook, target: "ftp://example.com"}]
+`,
+			wantErr: []string{
+				"line 4: locations[0].id: '-nyc' must start with a letter or digit",
+				"line 6: locations[1].coordinates: invalid latitude '95'",
+				"line 8: locations[2].poll.forecast: 'soon' must be a positive duration",
+				"line 9: locations[2].notify[0].target: 'ftp://example.com' is not an http(s) URL",
+			},
+		},
+		{name: "missing locations", config: `defaults: {days: 1}`, wantErr: []string{"locations: missing required key"}},
+		{name: "empty locations", config: `locations: []`, wantErr: []string{"no locations defined"}},
+		{name: "empty document", config: ``, wantErr: []string{"config is empty"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseConfig([]byte(tt.config))
+			if len(tt.wantErr) > 0 {
+				if err == nil {
+					t.Fatalf("ParseConfig() = %+v, want errors %q", got, tt.wantErr)
+				}
+				lines := strings.Split(err.Error(), "\n")
+				if len(lines) != len(tt.wantErr) {
+					t.Fatalf("ParseConfig() error =\n%v\nwant %d errors", err, len(tt.wantErr))
+				}
+				for i, want := range tt.wantErr {
+					if !strings.Contains(lines[i], want) {
+						t.Errorf("error %d = %q, want %q", i, lines[i], want)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseConfig(): %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseConfig() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadLocationsExampleConfig(t *testing.T) {
+	requests, err := LoadLocations("../../locations.example.yaml")
+	if err != nil {
+		t.Fatalf("LoadLocations(): %v", err)
+	}
+	if len(requests) == 0 {
+		t.Error("example config defines no locations")
+	}
+}
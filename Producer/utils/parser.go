@@ -2,7 +2,9 @@ package utils
 
 import (
 	"bufio"
+	"encoding/csv"
 	"fmt"
+	"io"
 	"os"
 	"strconv"
 	"strings"
@@ -10,23 +12,59 @@ import (
 	"github.com/abhijeet1999/weather/models"
 )
 
-// parseLine parses a single line in format "zipcode,days,temp_threshold,wind_threshold,humidity_threshold"
+// parseLine parses a single line in format "location,days,temp_threshold,wind_threshold,humidity_threshold[,country[,name]]".
+// The location is a ZIP code, a "lat,lon" pair or a quoted "City,State,Country" name.
 func parseLine(line string) (models.WeatherRequest, error) {
-	parts := strings.Split(line, ",")
-
-	// Check for correct number of fields
-	if len(parts) != 5 {
-		if len(parts) < 5 {
-			return models.WeatherRequest{}, fmt.Errorf("invalid format: expected 5 fields (zipcode,days,temp,wind,humidity), got %d fields in '%s'", len(parts), line)
-		} else {
-			return models.WeatherRequest{}, fmt.Errorf("invalid format: expected 5 fields (zipcode,days,temp,wind,humidity), got %d fields in '%s' (extra fields detected)", len(parts), line)
+	reader := csv.NewReader(strings.NewReader(line))
+	reader.TrimLeadingSpace = true
+	parts, err := reader.Read()
+	if err != nil {
+		return models.WeatherRequest{}, fmt.Errorf("invalid format in '%s': %v", line, err)
+	}
+
+	// An unquoted "lat,lon" pair spans the first two fields
+	location := models.WeatherRequest{}
+	if len(parts) >= 6 && isCoordinatePair(parts[0], parts[1], parts[2]) {
+		location, err = parseCoordinates(parts[0], parts[1])
+		if err != nil {
+			return models.WeatherRequest{}, err
 		}
+		parts = append([]string{parts[0] + "," + parts[1]}, parts[2:]...)
 	}
 
-	// Validate and parse zip code
-	zipCode := strings.TrimSpace(parts[0])
-	if err := validateZipCode(zipCode); err != nil {
-		return models.WeatherRequest{}, fmt.Errorf("invalid zip code: %v", err)
+	// Check for correct number of fields (country and name are optional)
+	if len(parts) < 5 {
+		return models.WeatherRequest{}, fmt.Errorf("invalid format: expected 5 to 7 fields (location,days,temp,wind,humidity[,country[,name]]), got %d fields in '%s'", len(parts), line)
+	}
+	if len(parts) > 7 {
+		return models.WeatherRequest{}, fmt.Errorf("invalid format: expected 5 to 7 fields (location,days,temp,wind,humidity[,country[,name]]), got %d fields in '%s' (extra fields detected)", len(parts), line)
+	}
+
+	// Validate and parse country; it may be left empty when only a name is given
+	country := ""
+	if len(parts) >= 6 && strings.TrimSpace(parts[5]) != "" {
+		country, err = NormalizeCountry(parts[5])
+		if err != nil {
+			return models.WeatherRequest{}, fmt.Errorf("invalid country: %v", err)
+		}
+	}
+
+	// Validate and parse location
+	if !location.HasCoordinates {
+		location, err = parseLocation(strings.TrimSpace(parts[0]), country)
+		if err != nil {
+			return models.WeatherRequest{}, err
+		}
+	}
+	if country != "" {
+		// The country in a city name must agree with the country column
+		if location.City != "" && location.Country != country {
+			return models.WeatherRequest{}, fmt.Errorf("invalid country: '%s' conflicts with country '%s' of city '%s'", country, location.Country, strings.TrimSpace(parts[0]))
+		}
+		location.Country = country
+	}
+	if len(parts) == 7 {
+		location.Name = strings.TrimSpace(parts[6])
 	}
 
 	// Validate and parse days
@@ -35,8 +73,8 @@ func parseLine(line string) (models.WeatherRequest, error) {
 	if err != nil {
 		return models.WeatherRequest{}, fmt.Errorf("invalid days value '%s': must be a number", daysStr)
 	}
-	if days < 1 || days > 5 {
-		return models.WeatherRequest{}, fmt.Errorf("invalid days value %d: must be between 1 and 5", days)
+	if err := validateDays(days); err != nil {
+		return models.WeatherRequest{}, err
 	}
 
 	// Validate and parse temperature threshold
@@ -45,8 +83,8 @@ func parseLine(line string) (models.WeatherRequest, error) {
 	if err != nil {
 		return models.WeatherRequest{}, fmt.Errorf("invalid temperature threshold '%s': must be a number", tempStr)
 	}
-	if alertTemp < -50 || alertTemp > 60 {
-		return models.WeatherRequest{}, fmt.Errorf("invalid temperature threshold %.1f: must be between -50°C and 60°C", alertTemp)
+	if err := validateTempThreshold(alertTemp); err != nil {
+		return models.WeatherRequest{}, err
 	}
 
 	// Validate and parse wind threshold
@@ -55,8 +93,8 @@ func parseLine(line string) (models.WeatherRequest, error) {
 	if err != nil {
 		return models.WeatherRequest{}, fmt.Errorf("invalid wind threshold '%s': must be a number", windStr)
 	}
-	if alertWind < 0 || alertWind > 100 {
-		return models.WeatherRequest{}, fmt.Errorf("invalid wind threshold %.1f: must be between 0 and 100 m/s", alertWind)
+	if err := validateWindThreshold(alertWind); err != nil {
+		return models.WeatherRequest{}, err
 	}
 
 	// Validate and parse humidity threshold
@@ -65,17 +103,137 @@ func parseLine(line string) (models.WeatherRequest, error) {
 	if err != nil {
 		return models.WeatherRequest{}, fmt.Errorf("invalid humidity threshold '%s': must be a number", humidityStr)
 	}
-	if alertHumidity < 0 || alertHumidity > 100 {
-		return models.WeatherRequest{}, fmt.Errorf("invalid humidity threshold %d: must be between 0 and 100%%", alertHumidity)
+	if err := validateHumidityThreshold(alertHumidity); err != nil {
+		return models.WeatherRequest{}, err
+	}
+
+	location.Days = days
+	location.AlertTemp = float32(alertTemp)
+	location.AlertWind = float32(alertWind)
+	location.AlertHumidity = alertHumidity
+	return location, nil
+}
+
+// validateDays checks the number of forecast days
+func validateDays(days int) error {
+	if days < 1 || days > 5 {
+		return fmt.Errorf("invalid days value %d: must be between 1 and 5", days)
+	}
+	return nil
+}
+
+// validateTempThreshold checks a temperature alert threshold in Celsius
+func validateTempThreshold(temp float64) error {
+	if temp < -50 || temp > 60 {
+		return fmt.Errorf("invalid temperature threshold %.1f: must be between -50°C and 60°C", temp)
+	}
+	return nil
+}
+
+// validateWindThreshold checks a wind speed alert threshold in m/s
+func validateWindThreshold(wind float64) error {
+	if wind < 0 || wind > 100 {
+		return fmt.Errorf("invalid wind threshold %.1f: must be between 0 and 100 m/s", wind)
+	}
+	return nil
+}
+
+// validateHumidityThreshold checks a humidity alert threshold in percent
+func validateHumidityThreshold(humidity int) error {
+	if humidity < 0 || humidity > 100 {
+		return fmt.Errorf("invalid humidity threshold %d: must be between 0 and 100%%", humidity)
+	}
+	return nil
+}
+
+// parseLocation parses a location field holding a ZIP code, a "lat,lon" pair or a "City,State,Country" name
+func parseLocation(field, country string) (models.WeatherRequest, error) {
+	if !strings.Contains(field, ",") {
+		if country == "" {
+			country = DefaultCountry
+		}
+		zipCode, err := ValidatePostalCode(field, country)
+		if err != nil {
+			return models.WeatherRequest{}, fmt.Errorf("invalid zip code: %v", err)
+		}
+		return models.WeatherRequest{ZipCode: zipCode, Country: country}, nil
+	}
+
+	parts := strings.Split(field, ",")
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+
+	if len(parts) == 2 && isNumber(parts[0]) && isNumber(parts[1]) {
+		return parseCoordinates(parts[0], parts[1])
+	}
+
+	return parseCityName(parts)
+}
+
+// parseCoordinates validates a latitude and longitude pair
+func parseCoordinates(latStr, lonStr string) (models.WeatherRequest, error) {
+	lat, err := strconv.ParseFloat(strings.TrimSpace(latStr), 64)
+	if err != nil || !(lat >= -90 && lat <= 90) {
+		return models.WeatherRequest{}, fmt.Errorf("invalid latitude '%s': must be a number between -90 and 90", latStr)
+	}
+
+	lon, err := strconv.ParseFloat(strings.TrimSpace(lonStr), 64)
+	if err != nil || !(lon >= -180 && lon <= 180) {
+		return models.WeatherRequest{}, fmt.Errorf("invalid longitude '%s': must be a number between -180 and 180", lonStr)
+	}
+
+	return models.WeatherRequest{Lat: lat, Lon: lon, HasCoordinates: true}, nil
+}
+
+// parseCityName validates a "City[,State],Country" name; the country part is normalized to ISO 3166-1 alpha-2
+func parseCityName(parts []string) (models.WeatherRequest, error) {
+	name := strings.Join(parts, ",")
+	if len(parts) > 3 {
+		return models.WeatherRequest{}, fmt.Errorf("invalid city name '%s': expected City, City,Country or City,State,Country", name)
+	}
+	for _, part := range parts {
+		if part == "" {
+			return models.WeatherRequest{}, fmt.Errorf("invalid city name '%s': empty name component", name)
+		}
+	}
+
+	country, err := NormalizeCountry(parts[len(parts)-1])
+	if err != nil {
+		return models.WeatherRequest{}, fmt.Errorf("invalid city name '%s': %v", name, err)
 	}
+	parts[len(parts)-1] = country
 
-	return models.WeatherRequest{
-		ZipCode:       zipCode,
-		Days:          days,
-		AlertTemp:     float32(alertTemp),
-		AlertWind:     float32(alertWind),
-		AlertHumidity: alertHumidity,
-	}, nil
+	return models.WeatherRequest{City: strings.Join(parts, ","), Country: country}, nil
+}
+
+// isCoordinatePair reports whether the leading fields of a line hold an unquoted "lat,lon" pair rather than a ZIP code
+// and the days value. Decimal degrees such as "40.7128,-74.0060" always count; whole degrees such as "40,74" or "0,0"
+// only when both are in range and the field after them is a valid days value.
+func isCoordinatePair(latStr, lonStr, next string) bool {
+	if isCoordinate(latStr) && isCoordinate(lonStr) {
+		return true
+	}
+
+	lat, latErr := strconv.ParseFloat(strings.TrimSpace(latStr), 64)
+	lon, lonErr := strconv.ParseFloat(strings.TrimSpace(lonStr), 64)
+	if latErr != nil || lonErr != nil || !(lat >= -90 && lat <= 90) || !(lon >= -180 && lon <= 180) {
+		return false
+	}
+
+	days, err := strconv.Atoi(strings.TrimSpace(next))
+	return err == nil && validateDays(days) == nil
+}
+
+// isCoordinate reports whether a field looks like a decimal latitude or longitude rather than a postal code
+func isCoordinate(field string) bool {
+	return strings.ContainsAny(field, ".-") && isNumber(field)
+}
+
+// isNumber reports whether a field holds a number
+func isNumber(field string) bool {
+	_, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+	return err == nil
 }
 
 // validateZipCode validates a US zip code format
@@ -117,8 +275,13 @@ func ParseInputFile(filename string) ([]models.WeatherRequest, error) {
 	}
 	defer file.Close()
 
+	return parseInput(file)
+}
+
+// parseInput parses input.txt lines from a reader
+func parseInput(r io.Reader) ([]models.WeatherRequest, error) {
 	var requests []models.WeatherRequest
-	scanner := bufio.NewScanner(file)
+	scanner := bufio.NewScanner(r)
 	lineNumber := 0
 
 	for scanner.Scan() {
@@ -0,0 +1,169 @@
+package utils
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/abhijeet1999/weather/models"
+)
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		name       string
+		line       string
+		want       models.WeatherRequest
+		locationID string
+		wantErr    string
+	}{
+		{
+			name:       "US ZIP code without country",
+			line:       "10001,3,30,15,85",
+			want:       models.WeatherRequest{ZipCode: "10001", Country: "US", Days: 3, AlertTemp: 30, AlertWind: 15, AlertHumidity: 85},
+			locationID: "10001",
+		},
+		{
+			name:       "US ZIP+4 code",
+			line:       "12601-1234,1,20,10,90,US",
+			want:       models.WeatherRequest{ZipCode: "12601-1234", Country: "US", Days: 1, AlertTemp: 20, AlertWind: 10, AlertHumidity: 90},
+			locationID: "12601-1234",
+		},
+		{
+			name:       "German postal code is prefixed with the country",
+			line:       "10115,2,25,12,80,DE",
+			want:       models.WeatherRequest{ZipCode: "10115", Country: "DE", Days: 2, AlertTemp: 25, AlertWind: 12, AlertHumidity: 80},
+			locationID: "DE:10115",
+		},
+		{
+			name:       "Canadian postal code is normalized",
+			line:       "m5v3l9,2,25,12,80,ca",
+			want:       models.WeatherRequest{ZipCode: "M5V 3L9", Country: "CA", Days: 2, AlertTemp: 25, AlertWind: 12, AlertHumidity: 80},
+			locationID: "CA:M5V 3L9",
+		},
+		{
+			name:       "UK alias maps to GB",
+			line:       "SW1A1AA,2,20,15,90,UK",
+			want:       models.WeatherRequest{ZipCode: "SW1A 1AA", Country: "GB", Days: 2, AlertTemp: 20, AlertWind: 15, AlertHumidity: 90},
+			locationID: "GB:SW1A 1AA",
+		},
+		{
+			name:       "generic postal code format",
+			line:       "1010,2,20,15,90,AT",
+			want:       models.WeatherRequest{ZipCode: "1010", Country: "AT", Days: 2, AlertTemp: 20, AlertWind: 15, AlertHumidity: 90},
+			locationID: "AT:1010",
+		},
+		{
+			name:       "decimal coordinates",
+			line:       "40.7128,-74.0060,3,30,15,85",
+			want:       models.WeatherRequest{Lat: 40.7128, Lon: -74.006, HasCoordinates: true, Days: 3, AlertTemp: 30, AlertWind: 15, AlertHumidity: 85},
+			locationID: "40.7128,-74.0060",
+		},
+		{
+			name:       "quoted coordinates with a country",
+			line:       `"27.9506,-90.1234",3,30,25,95,US`,
+			want:       models.WeatherRequest{Lat: 27.9506, Lon: -90.1234, HasCoordinates: true, Country: "US", Days: 3, AlertTemp: 30, AlertWind: 25, AlertHumidity: 95},
+			locationID: "27.9506,-90.1234",
+		},
+		{
+			name:       "whole-degree coordinates",
+			line:       "40,74,3,30,15,85",
+			want:       models.WeatherRequest{Lat: 40, Lon: 74, HasCoordinates: true, Days: 3, AlertTemp: 30, AlertWind: 15, AlertHumidity: 85},
+			locationID: "40.0000,74.0000",
+		},
+		{
+			name:       "null island",
+			line:       "0,0,1,30,15,85",
+			want:       models.WeatherRequest{HasCoordinates: true, Days: 1, AlertTemp: 30, AlertWind: 15, AlertHumidity: 85},
+			locationID: "0.0000,0.0000",
+		},
+		{
+			name:       "quoted whole-degree coordinates",
+			line:       `"0,0",1,30,15,85`,
+			want:       models.WeatherRequest{HasCoordinates: true, Days: 1, AlertTemp: 30, AlertWind: 15, AlertHumidity: 85},
+			locationID: "0.0000,0.0000",
+		},
+		{
+			name:       "city, state and country",
+			line:       `"Poughkeepsie,NY,usa",2,20,15,85`,
+			want:       models.WeatherRequest{City: "Poughkeepsie,NY,US", Country: "US", Days: 2, AlertTemp: 20, AlertWind: 15, AlertHumidity: 85},
+			locationID: "Poughkeepsie,NY,US",
+		},
+		{
+			name:       "city with a matching country column",
+			line:       `"Toronto,ON,CA",2,20,15,85,ca`,
+			want:       models.WeatherRequest{City: "Toronto,ON,CA", Country: "CA", Days: 2, AlertTemp: 20, AlertWind: 15, AlertHumidity: 85},
+			locationID: "Toronto,ON,CA",
+		},
+		{
+			name:       "display name after the country",
+			line:       "10115,2,25,12,80,DE,Berlin Mitte",
+			want:       models.WeatherRequest{ZipCode: "10115", Country: "DE", Name: "Berlin Mitte", Days: 2, AlertTemp: 25, AlertWind: 12, AlertHumidity: 80},
+			locationID: "DE:10115",
+		},
+		{
+			name:       "display name with an empty country column",
+			line:       "10001,3,30,15,85,,Chelsea",
+			want:       models.WeatherRequest{ZipCode: "10001", Country: "US", Name: "Chelsea", Days: 3, AlertTemp: 30, AlertWind: 15, AlertHumidity: 85},
+			locationID: "10001",
+		},
+		{
+			name:       "display name of coordinates",
+			line:       "40.7128,-74.0060,3,30,15,85,, Lower Manhattan ",
+			want:       models.WeatherRequest{Lat: 40.7128, Lon: -74.006, HasCoordinates: true, Name: "Lower Manhattan", Days: 3, AlertTemp: 30, AlertWind: 15, AlertHumidity: 85},
+			locationID: "40.7128,-74.0060",
+		},
+		{name: "city with a conflicting country column", line: `"Toronto,ON,CA",2,20,15,85,US`, wantErr: "'US' conflicts with country 'CA' of city 'Toronto,ON,CA'"},
+		{name: "whole numbers followed by a non-days value are not coordinates", line: "40,74,30,15,85,US", wantErr: "invalid zip code"},
+		{name: "latitude out of range", line: "91.5,-74.0,3,30,15,85", wantErr: "invalid latitude"},
+		{name: "quoted longitude out of range", line: `"45,200",3,30,15,85`, wantErr: "invalid longitude"},
+		{name: "city name with an invalid country", line: `"Springfield,Illinois",3,30,15,85`, wantErr: "invalid city name"},
+		{name: "invalid US ZIP code", line: "1000A,3,30,15,85", wantErr: "invalid zip code"},
+		{name: "invalid German postal code", line: "1011,3,30,15,85,DE", wantErr: "invalid zip code"},
+		{name: "invalid country", line: "10001,3,30,15,85,USAA", wantErr: "invalid country"},
+		{name: "too few fields", line: "10001,3,30,15", wantErr: "got 4 fields"},
+		{name: "too many fields", line: "10001,3,30,15,85,US,Chelsea,extra", wantErr: "got 8 fields"},
+		{name: "days out of range", line: "10001,6,30,15,85", wantErr: "invalid days value"},
+		{name: "temperature out of range", line: "10001,3,70,15,85", wantErr: "invalid temperature threshold"},
+		{name: "wind not a number", line: "10001,3,30,fast,85", wantErr: "invalid wind threshold"},
+		{name: "humidity out of range", line: "10001,3,30,15,101", wantErr: "invalid humidity threshold"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseLine(tt.line)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseLine(%q) error = %v, want %q", tt.line, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseLine(%q): %v", tt.line, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseLine(%q) = %+v, want %+v", tt.line, got, tt.want)
+			}
+			if id := got.LocationID(); id != tt.locationID {
+				t.Errorf("LocationID() = %q, want %q", id, tt.locationID)
+			}
+		})
+	}
+}
+
+func TestLocationIDsOfSameZipCodeInDifferentCountries(t *testing.T) {
+	us, err := parseLine("10115,3,30,15,85,US")
+	if err != nil {
+		t.Fatal(err)
+	}
+	de, err := parseLine("10115,3,30,15,85,DE")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if us.LocationID() == de.LocationID() {
+		t.Errorf("US and DE 10115 share location ID %q", us.LocationID())
+	}
+	if us.LocationKey() == de.LocationKey() {
+		t.Errorf("US and DE 10115 share location key %q", us.LocationKey())
+	}
+}
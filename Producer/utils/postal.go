@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/abhijeet1999/weather/models"
+)
+
+// DefaultCountry is used when an input line has no country column
+const DefaultCountry = models.DefaultCountry
+
+// postalCodeFormat describes the accepted postal code pattern for a country
+type postalCodeFormat struct {
+	pattern     *regexp.Regexp
+	description string
+	normalize   func(string) string
+}
+
+// postalCodeFormats holds country-specific postal code rules; other countries use genericPostalCode
+var postalCodeFormats = map[string]postalCodeFormat{
+	"CA": {
+		pattern:     regexp.MustCompile(`^[ABCEGHJ-NPRSTVXY][0-9][ABCEGHJ-NPRSTV-Z] ?[0-9][ABCEGHJ-NPRSTV-Z][0-9]$`),
+		description: "A1A 1A1",
+		normalize:   insertSpaceBeforeLast(3),
+	},
+	"GB": {
+		pattern:     regexp.MustCompile(`^[A-Z]{1,2}[0-9][A-Z0-9]? ?[0-9][A-Z]{2}$`),
+		description: "e.g. SW1A 1AA",
+		normalize:   insertSpaceBeforeLast(3),
+	},
+	"DE": {
+		pattern:     regexp.MustCompile(`^[0-9]{5}$`),
+		description: "5 digits",
+	},
+}
+
+// genericPostalCode accepts alphanumeric codes with optional spaces or dashes
+var genericPostalCode = regexp.MustCompile(`^[A-Z0-9][A-Z0-9 \-]{1,9}$`)
+
+// countryAliases maps common non-ISO country codes to ISO 3166-1 alpha-2
+var countryAliases = map[string]string{
+	"UK":  "GB",
+	"USA": "US",
+	"DEU": "DE",
+	"CAN": "CA",
+	"GBR": "GB",
+}
+
+// NormalizeCountry validates a country code and returns its ISO 3166-1 alpha-2 form
+func NormalizeCountry(country string) (string, error) {
+	code := strings.ToUpper(strings.TrimSpace(country))
+	if code == "" {
+		return DefaultCountry, nil
+	}
+
+	if alias, exists := countryAliases[code]; exists {
+		code = alias
+	}
+
+	if len(code) != 2 || code[0] < 'A' || code[0] > 'Z' || code[1] < 'A' || code[1] > 'Z' {
+		return "", fmt.Errorf("'%s' is not a valid country code (expected ISO 3166-1 alpha-2, e.g. US, CA, GB, DE)", country)
+	}
+
+	return code, nil
+}
+
+// ValidatePostalCode validates a postal code for a country and returns it in normalized form
+func ValidatePostalCode(postalCode, country string) (string, error) {
+	if country == DefaultCountry {
+		return postalCode, validateZipCode(postalCode)
+	}
+
+	code := strings.ToUpper(strings.TrimSpace(postalCode))
+	if code == "" {
+		return "", fmt.Errorf("postal code cannot be empty")
+	}
+
+	format, exists := postalCodeFormats[country]
+	if !exists {
+		if !genericPostalCode.MatchString(code) {
+			return "", fmt.Errorf("'%s' is not a valid postal code", postalCode)
+		}
+		return code, nil
+	}
+
+	if !format.pattern.MatchString(code) {
+		return "", fmt.Errorf("'%s' is not a valid %s postal code format (expected %s)", postalCode, country, format.description)
+	}
+
+	if format.normalize != nil {
+		code = format.normalize(code)
+	}
+	return code, nil
+}
+
+// insertSpaceBeforeLast returns a normalizer that formats codes as "<outward> <last n chars>"
+func insertSpaceBeforeLast(n int) func(string) string {
+	return func(code string) string {
+		compact := strings.ReplaceAll(code, " ", "")
+		if len(compact) <= n {
+			return compact
+		}
+		return compact[:len(compact)-n] + " " + compact[len(compact)-n:]
+	}
+}
@@ -0,0 +1,49 @@
+package utils
+
+// Conversions to the SI units used by the consumer metrics and alert thresholds (°C, m/s).
+// UnitMetric values from the weather providers are already in these units.
+
+// NormalizeUnits returns the unit system to request from weather providers; anything but imperial means metric
+func NormalizeUnits(units string) string {
+	if units == UnitImperial {
+		return UnitImperial
+	}
+	return UnitMetric
+}
+
+// IsValidUnits reports whether units names a supported unit system
+func IsValidUnits(units string) bool {
+	return units == UnitMetric || units == UnitImperial
+}
+
+// TemperatureSymbol returns the temperature unit symbol for a unit system
+func TemperatureSymbol(units string) string {
+	if units == UnitImperial {
+		return "°F"
+	}
+	return "°C"
+}
+
+// WindSpeedSymbol returns the wind speed unit symbol for a unit system
+func WindSpeedSymbol(units string) string {
+	if units == UnitImperial {
+		return "mph"
+	}
+	return "m/s"
+}
+
+// ToCelsius converts a temperature reported in the given unit system to °C
+func ToCelsius(temp float32, units string) float32 {
+	if units == UnitImperial {
+		return (temp - 32) * 5 / 9
+	}
+	return temp
+}
+
+// ToMetersPerSecond converts a wind speed reported in the given unit system to m/s
+func ToMetersPerSecond(speed float32, units string) float32 {
+	if units == UnitImperial {
+		return speed * 0.44704 // 1 mph = 0.44704 m/s
+	}
+	return speed
+}
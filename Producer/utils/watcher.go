@@ -0,0 +1,113 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"log"
+	"os"
+	"time"
+
+	"github.com/abhijeet1999/weather/models"
+)
+
+// ApplyFunc applies a freshly loaded set of locations; returning an error rejects the reload
+type ApplyFunc func(requests []models.WeatherRequest) error
+
+// LocationsWatcher reloads a locations file when its content changes or a reload is requested (e.g. on SIGHUP).
+// Files that fail validation are rejected and the previously applied locations keep running.
+type LocationsWatcher struct {
+	path     string
+	interval time.Duration
+	apply    ApplyFunc
+	reload   chan struct{}
+	checksum []byte
+}
+
+// NewLocationsWatcher creates a new LocationsWatcher instance.
+// The file is checked every interval; a zero interval disables polling so only Reload triggers a reload.
+func NewLocationsWatcher(path string, interval time.Duration, apply ApplyFunc) *LocationsWatcher {
+	w := &LocationsWatcher{
+		path:     path,
+		interval: interval,
+		apply:    apply,
+		reload:   make(chan struct{}, 1),
+	}
+
+	// The file as read at startup is already applied
+	if data, err := os.ReadFile(path); err == nil {
+		w.checksum = checksum(data)
+	}
+
+	return w
+}
+
+// Reload requests a reload even if the file content is unchanged
+func (w *LocationsWatcher) Reload() {
+	select {
+	case w.reload <- struct{}{}:
+	default: // A reload is already pending
+	}
+}
+
+// Run watches the file until ctx is cancelled
+func (w *LocationsWatcher) Run(ctx context.Context) {
+	var tick <-chan time.Time
+	if w.interval > 0 {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	log.Printf("👀 Watching %s for location changes", w.path)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick:
+			w.check(false)
+		case <-w.reload:
+			w.check(true)
+		}
+	}
+}
+
+// check loads and applies the file if it changed or force is set
+func (w *LocationsWatcher) check(force bool) {
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		if force {
+			log.Printf("❌ Rejected reload of %s, keeping previous locations: %v", w.path, err)
+		}
+		return
+	}
+
+	sum := checksum(data)
+	if !force && bytes.Equal(sum, w.checksum) {
+		return
+	}
+	// Remember the content even if it is rejected so a broken file is reported once, not on every tick
+	w.checksum = sum
+
+	log.Printf("🔄 Reloading locations from %s...", w.path)
+
+	requests, err := parseLocations(w.path, data)
+	if err != nil {
+		log.Printf("❌ Rejected reload of %s, keeping previous locations: %v", w.path, err)
+		return
+	}
+
+	if err := w.apply(requests); err != nil {
+		log.Printf("❌ Rejected reload of %s, keeping previous locations: %v", w.path, err)
+		return
+	}
+
+	log.Printf("✅ Reloaded %d locations from %s", len(requests), w.path)
+}
+
+// checksum returns the SHA-256 digest of the file content
+func checksum(data []byte) []byte {
+	sum := sha256.Sum256(data)
+	return sum[:]
+}
@@ -0,0 +1,150 @@
+package weather
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// RetryPolicy controls how failed weather API calls are retried
+type RetryPolicy struct {
+	MaxAttempts int           // Total attempts including the first call
+	BaseDelay   time.Duration // Delay before the first retry, doubled on each attempt
+	MaxDelay    time.Duration // Upper bound for a single backoff delay
+}
+
+// DefaultRetryPolicy is used when no policy is configured
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    30 * time.Second,
+}
+
+// RequestObserver is called after every HTTP request to a weather API with the endpoint, the request latency
+// and the error, or nil if it succeeded. Retried attempts are observed individually.
+type RequestObserver func(endpoint string, duration time.Duration, err *APIError)
+
+// apiClient performs HTTP requests on behalf of the weather providers
+type apiClient struct {
+	httpClient *http.Client
+	apiKey     func() string
+	userAgent  string
+	retry      RetryPolicy
+	limiter    *RateLimiter
+	quota      *QuotaTracker
+	observe    RequestObserver // Nil when requests are not observed
+}
+
+// getJSON fetches a URL and decodes the JSON response body into v, retrying transient failures
+func (c *apiClient) getJSON(ctx context.Context, endpoint, url string, v interface{}) error {
+	attempts := c.retry.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var apiErr *APIError
+	for attempt := 1; attempt <= attempts; attempt++ {
+		apiErr = c.doGetJSON(ctx, endpoint, url, v)
+		if apiErr == nil {
+			return nil
+		}
+		apiErr.Attempts = attempt
+
+		if !apiErr.Retryable() || attempt == attempts || ctx.Err() != nil {
+			break
+		}
+
+		delay := c.backoff(attempt, apiErr.RetryAfter)
+		if delay < 0 {
+			// Server asked us to wait longer than the policy allows
+			break
+		}
+
+		log.Printf("🔁 %s failed (%s), retrying in %s (attempt %d/%d)",
+			endpoint, apiErr.Kind, delay.Round(time.Millisecond), attempt+1, attempts)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return apiErr
+		case <-timer.C:
+		}
+	}
+
+	return apiErr
+}
+
+// doGetJSON performs a single rate-limited request and classifies any failure
+func (c *apiClient) doGetJSON(ctx context.Context, endpoint, url string, v interface{}) *APIError {
+	if c.limiter != nil {
+		if err := c.limiter.Wait(ctx); err != nil {
+			return &APIError{Kind: ErrorKindNetwork, Endpoint: endpoint, Err: err}
+		}
+	}
+	if c.quota != nil && !c.quota.Take() {
+		return &APIError{Kind: ErrorKindQuota, Endpoint: endpoint, Err: ErrQuota}
+	}
+
+	start := time.Now()
+	apiErr := c.request(ctx, endpoint, url, v)
+	if c.observe != nil {
+		c.observe(endpoint, time.Since(start), apiErr)
+	}
+	return apiErr
+}
+
+// request performs a single HTTP request and decodes the JSON response body into v
+func (c *apiClient) request(ctx context.Context, endpoint, url string, v interface{}) *APIError {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return &APIError{Kind: ErrorKindClient, Endpoint: endpoint, Err: err}
+	}
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+
+	r, err := c.httpClient.Do(req)
+	if err != nil {
+		return &APIError{Kind: ErrorKindNetwork, Endpoint: endpoint, Err: err}
+	}
+	defer r.Body.Close()
+
+	if r.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, io.LimitReader(r.Body, 64*1024)) // Allow connection reuse
+		return newStatusError(endpoint, r)
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return &APIError{Kind: ErrorKindNetwork, Endpoint: endpoint, Err: err}
+		}
+		return &APIError{Kind: ErrorKindDecode, Endpoint: endpoint, StatusCode: r.StatusCode, Err: err}
+	}
+
+	return nil
+}
+
+// backoff returns the delay before the next attempt, honoring Retry-After.
+// A negative result means the requested delay exceeds the policy maximum.
+func (c *apiClient) backoff(attempt int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		if c.retry.MaxDelay > 0 && retryAfter > c.retry.MaxDelay {
+			return -1
+		}
+		return retryAfter
+	}
+
+	delay := c.retry.BaseDelay << (attempt - 1)
+	if c.retry.MaxDelay > 0 && (delay > c.retry.MaxDelay || delay <= 0) {
+		delay = c.retry.MaxDelay
+	}
+
+	// Add up to 20% jitter so concurrent callers don't retry in lockstep
+	return delay + time.Duration(rand.Float64()*0.2*float64(delay))
+}
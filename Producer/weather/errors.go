@@ -0,0 +1,155 @@
+package weather
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ErrorKind classifies weather API failures
+type ErrorKind string
+
+// Weather API error kinds
+const (
+	ErrorKindRateLimited  ErrorKind = "rate_limited" // 429 Too Many Requests
+	ErrorKindUnauthorized ErrorKind = "unauthorized" // 401 invalid or missing API key
+	ErrorKindNotFound     ErrorKind = "not_found"    // 404 unknown location
+	ErrorKindServer       ErrorKind = "server"       // 5xx provider outage
+	ErrorKindClient       ErrorKind = "client"       // Other 4xx responses
+	ErrorKindDecode       ErrorKind = "decode"       // Response body could not be decoded
+	ErrorKindNetwork      ErrorKind = "network"      // Transport failure or timeout
+	ErrorKindQuota        ErrorKind = "quota"        // Local daily quota exhausted, no call made
+)
+
+// Sentinel errors matched by APIError.Is, for use with errors.Is
+var (
+	ErrRateLimited  = errors.New("weather API rate limit exceeded")
+	ErrUnauthorized = errors.New("weather API key rejected")
+	ErrNotFound     = errors.New("weather API location not found")
+	ErrServer       = errors.New("weather API server error")
+	ErrClient       = errors.New("weather API client error")
+	ErrDecode       = errors.New("weather API response could not be decoded")
+	ErrNetwork      = errors.New("weather API network error")
+	ErrQuota        = errors.New("weather API daily quota exhausted")
+
+	// ErrDeferred is returned when a low-priority location is skipped to save quota
+	ErrDeferred = errors.New("poll deferred to preserve daily quota")
+)
+
+// APIError describes a failed weather API call
+type APIError struct {
+	Kind       ErrorKind
+	Endpoint   string
+	StatusCode int
+	Status     string
+	RetryAfter time.Duration // Delay requested by the server, if any
+	Attempts   int
+	Err        error
+}
+
+// Error implements the error interface
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("%s request failed (%s)", e.Endpoint, e.Kind)
+	if e.Status != "" {
+		msg += ": " + e.Status
+	} else if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	if e.Attempts > 1 {
+		msg += fmt.Sprintf(" after %d attempts", e.Attempts)
+	}
+	return msg
+}
+
+// Unwrap returns the underlying error
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is the sentinel error for this error's kind
+func (e *APIError) Is(target error) bool {
+	return target == kindSentinel(e.Kind)
+}
+
+// Retryable reports whether the call may succeed if attempted again later
+func (e *APIError) Retryable() bool {
+	switch e.Kind {
+	case ErrorKindRateLimited, ErrorKindServer, ErrorKindNetwork:
+		return true
+	default:
+		return false
+	}
+}
+
+// kindSentinel returns the sentinel error for an error kind
+func kindSentinel(kind ErrorKind) error {
+	switch kind {
+	case ErrorKindRateLimited:
+		return ErrRateLimited
+	case ErrorKindUnauthorized:
+		return ErrUnauthorized
+	case ErrorKindNotFound:
+		return ErrNotFound
+	case ErrorKindServer:
+		return ErrServer
+	case ErrorKindClient:
+		return ErrClient
+	case ErrorKindDecode:
+		return ErrDecode
+	case ErrorKindNetwork:
+		return ErrNetwork
+	case ErrorKindQuota:
+		return ErrQuota
+	default:
+		return nil
+	}
+}
+
+// newStatusError classifies a non-200 HTTP response
+func newStatusError(endpoint string, r *http.Response) *APIError {
+	apiErr := &APIError{
+		Endpoint:   endpoint,
+		StatusCode: r.StatusCode,
+		Status:     r.Status,
+		RetryAfter: parseRetryAfter(r.Header.Get("Retry-After"), time.Now()),
+	}
+
+	switch {
+	case r.StatusCode == http.StatusTooManyRequests:
+		apiErr.Kind = ErrorKindRateLimited
+	case r.StatusCode == http.StatusUnauthorized || r.StatusCode == http.StatusForbidden:
+		apiErr.Kind = ErrorKindUnauthorized
+	case r.StatusCode == http.StatusNotFound:
+		apiErr.Kind = ErrorKindNotFound
+	case r.StatusCode >= 500:
+		apiErr.Kind = ErrorKindServer
+	default:
+		apiErr.Kind = ErrorKindClient
+	}
+
+	return apiErr
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if at, err := http.ParseTime(value); err == nil {
+		if delay := at.Sub(now); delay > 0 {
+			return delay
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,398 @@
+package fakeowm
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// recordedFixtures holds the OpenWeatherMap responses bundled with the package
+//
+//go:embed fixtures
+var recordedFixtures embed.FS
+
+// Server serves recorded OpenWeatherMap fixtures so the pipeline can run offline.
+//
+// Fixtures are laid out as geo/<zip>.json, weather/<zip>.json and forecast/<zip>.json.
+// City name lookups match the name recorded in the geo fixtures.
+// Coordinate lookups are answered with the fixture of the nearest recorded ZIP code.
+type Server struct {
+	fixtures   fs.FS
+	apiKey     string
+	shiftTimes bool
+	now        func() time.Time
+	mux        *http.ServeMux
+}
+
+// Option configures a Server
+type Option func(*Server)
+
+// WithFixturesDir serves fixtures from a directory instead of the bundled recordings
+func WithFixturesDir(dir string) Option {
+	return func(s *Server) {
+		s.fixtures = os.DirFS(dir)
+	}
+}
+
+// WithAPIKey requires requests to carry a matching appid, returning 401 otherwise
+func WithAPIKey(key string) Option {
+	return func(s *Server) {
+		s.apiKey = key
+	}
+}
+
+// WithRecordedTimes serves fixture timestamps unchanged instead of shifting them to the present
+func WithRecordedTimes() Option {
+	return func(s *Server) {
+		s.shiftTimes = false
+	}
+}
+
+// New creates a new fake OpenWeatherMap server
+func New(opts ...Option) *Server {
+	sub, err := fs.Sub(recordedFixtures, "fixtures")
+	if err != nil {
+		panic(err) // The embedded directory is always present
+	}
+
+	s := &Server{
+		fixtures:   sub,
+		shiftTimes: true,
+		now:        time.Now,
+		mux:        http.NewServeMux(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.mux.HandleFunc("/data/2.5/weather", s.handleWeather)
+	s.mux.HandleFunc("/data/2.5/forecast", s.handleForecast)
+	s.mux.HandleFunc("/geo/1.0/zip", s.handleZip)
+	s.mux.HandleFunc("/geo/1.0/direct", s.handleDirect)
+
+	return s
+}
+
+// NewTestServer starts an httptest.Server backed by a fake OpenWeatherMap server
+func NewTestServer(opts ...Option) *httptest.Server {
+	return httptest.NewServer(New(opts...))
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🧪 Fake OpenWeatherMap request: %s %s", r.Method, r.URL.Path)
+
+	if r.Method != http.MethodGet {
+		s.sendError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	if s.apiKey != "" && r.URL.Query().Get("appid") != s.apiKey {
+		s.sendError(w, http.StatusUnauthorized, "Invalid API key. Please see https://openweathermap.org/faq#error401 for more info.")
+		return
+	}
+
+	s.mux.ServeHTTP(w, r)
+}
+
+// handleZip serves the geocoding fixture for a "zip,country" query
+func (s *Server) handleZip(w http.ResponseWriter, r *http.Request) {
+	zip := strings.SplitN(r.URL.Query().Get("zip"), ",", 2)[0]
+	if zip == "" {
+		s.sendError(w, http.StatusBadRequest, "invalid zip code")
+		return
+	}
+
+	data, err := fs.ReadFile(s.fixtures, path.Join("geo", zip+".json"))
+	if err != nil {
+		s.sendError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	s.sendJSON(w, data)
+}
+
+// handleDirect serves the geocoding fixtures whose city name matches a "City[,State][,Country]" query
+func (s *Server) handleDirect(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(r.URL.Query().Get("q"), ",")
+	name := strings.TrimSpace(parts[0])
+	if name == "" {
+		s.sendError(w, http.StatusBadRequest, "Nothing to geocode")
+		return
+	}
+	country := ""
+	if len(parts) > 1 {
+		country = strings.TrimSpace(parts[len(parts)-1])
+	}
+
+	entries, err := fs.ReadDir(s.fixtures, "geo")
+	if err != nil {
+		s.sendError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	matches := []json.RawMessage{}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+
+		data, err := fs.ReadFile(s.fixtures, path.Join("geo", entry.Name()))
+		if err != nil {
+			continue
+		}
+
+		var geo struct {
+			Name    string `json:"name"`
+			Country string `json:"country"`
+		}
+		if err := json.Unmarshal(data, &geo); err != nil {
+			continue
+		}
+
+		if strings.EqualFold(geo.Name, name) && (country == "" || strings.EqualFold(geo.Country, country)) {
+			matches = append(matches, data)
+		}
+	}
+
+	// The direct geocoding API answers unknown names with an empty list rather than 404
+	data, err := json.Marshal(matches)
+	if err != nil {
+		s.sendError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.sendJSON(w, data)
+}
+
+// handleWeather serves the current weather fixture nearest to the requested coordinates
+func (s *Server) handleWeather(w http.ResponseWriter, r *http.Request) {
+	s.serveByCoordinates(w, r, "weather", s.shiftWeather)
+}
+
+// handleForecast serves the forecast fixture nearest to the requested coordinates
+func (s *Server) handleForecast(w http.ResponseWriter, r *http.Request) {
+	s.serveByCoordinates(w, r, "forecast", s.shiftForecast)
+}
+
+// serveByCoordinates resolves lat/lon to a recorded ZIP code and serves its fixture
+func (s *Server) serveByCoordinates(w http.ResponseWriter, r *http.Request, kind string, shift func(map[string]interface{})) {
+	lat, errLat := strconv.ParseFloat(r.URL.Query().Get("lat"), 64)
+	lon, errLon := strconv.ParseFloat(r.URL.Query().Get("lon"), 64)
+	if errLat != nil || errLon != nil {
+		s.sendError(w, http.StatusBadRequest, "wrong latitude or longitude")
+		return
+	}
+
+	zip, err := s.nearestZip(lat, lon)
+	if err != nil {
+		s.sendError(w, http.StatusNotFound, "city not found")
+		return
+	}
+
+	data, err := fs.ReadFile(s.fixtures, path.Join(kind, zip+".json"))
+	if err != nil {
+		s.sendError(w, http.StatusNotFound, "city not found")
+		return
+	}
+
+	imperial := r.URL.Query().Get("units") == "imperial"
+	if s.shiftTimes || imperial {
+		var doc map[string]interface{}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		if err := decoder.Decode(&doc); err != nil {
+			s.sendError(w, http.StatusInternalServerError, fmt.Sprintf("invalid fixture %s/%s.json: %v", kind, zip, err))
+			return
+		}
+		if s.shiftTimes {
+			shift(doc)
+		}
+		if imperial {
+			toImperial(doc)
+		}
+		if data, err = json.Marshal(doc); err != nil {
+			s.sendError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	s.sendJSON(w, data)
+}
+
+// nearestZip returns the recorded ZIP code closest to the given coordinates
+func (s *Server) nearestZip(lat, lon float64) (string, error) {
+	entries, err := fs.ReadDir(s.fixtures, "geo")
+	if err != nil {
+		return "", err
+	}
+
+	nearest := ""
+	bestDistance := math.MaxFloat64
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+
+		data, err := fs.ReadFile(s.fixtures, path.Join("geo", entry.Name()))
+		if err != nil {
+			continue
+		}
+
+		var geo struct {
+			Lat float64 `json:"lat"`
+			Lon float64 `json:"lon"`
+		}
+		if err := json.Unmarshal(data, &geo); err != nil {
+			continue
+		}
+
+		distance := math.Hypot(geo.Lat-lat, geo.Lon-lon)
+		if distance < bestDistance {
+			bestDistance = distance
+			nearest = strings.TrimSuffix(entry.Name(), ".json")
+		}
+	}
+
+	if nearest == "" {
+		return "", fmt.Errorf("no geo fixtures found")
+	}
+	return nearest, nil
+}
+
+// shiftWeather moves a current weather fixture to the present
+func (s *Server) shiftWeather(doc map[string]interface{}) {
+	recorded, ok := int64Field(doc, "dt")
+	if !ok {
+		return
+	}
+
+	delta := s.now().Unix() - recorded
+	shiftField(doc, "dt", delta)
+	if sys, ok := doc["sys"].(map[string]interface{}); ok {
+		shiftField(sys, "sunrise", wholeDays(delta))
+		shiftField(sys, "sunset", wholeDays(delta))
+	}
+}
+
+// shiftForecast moves a forecast fixture so its first item is the next 3-hour slot
+func (s *Server) shiftForecast(doc map[string]interface{}) {
+	list, ok := doc["list"].([]interface{})
+	if !ok || len(list) == 0 {
+		return
+	}
+
+	first, ok := list[0].(map[string]interface{})
+	if !ok {
+		return
+	}
+	recorded, ok := int64Field(first, "dt")
+	if !ok {
+		return
+	}
+
+	const step = 3 * 60 * 60
+	nextSlot := (s.now().Unix()/step + 1) * step
+	delta := nextSlot - recorded
+
+	for _, raw := range list {
+		item, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if dt, ok := int64Field(item, "dt"); ok {
+			item["dt"] = dt + delta
+			item["dt_txt"] = time.Unix(dt+delta, 0).UTC().Format("2006-01-02 15:04:05")
+		}
+	}
+
+	if city, ok := doc["city"].(map[string]interface{}); ok {
+		shiftField(city, "sunrise", wholeDays(delta))
+		shiftField(city, "sunset", wholeDays(delta))
+	}
+}
+
+// toImperial converts a metric fixture (°C, m/s) to imperial units (°F, mph), as units=imperial requests
+func toImperial(doc map[string]interface{}) {
+	items := []interface{}{doc}
+	if list, ok := doc["list"].([]interface{}); ok {
+		items = list
+	}
+
+	for _, raw := range items {
+		item, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if main, ok := item["main"].(map[string]interface{}); ok {
+			for _, key := range []string{"temp", "feels_like", "temp_min", "temp_max"} {
+				convertField(main, key, func(c float64) float64 { return c*9/5 + 32 })
+			}
+		}
+		if wind, ok := item["wind"].(map[string]interface{}); ok {
+			for _, key := range []string{"speed", "gust"} {
+				convertField(wind, key, func(ms float64) float64 { return ms / 0.44704 })
+			}
+		}
+	}
+}
+
+// convertField applies a unit conversion to a numeric field, rounded to 2 decimals like the real API
+func convertField(doc map[string]interface{}, key string, convert func(float64) float64) {
+	number, ok := doc[key].(json.Number)
+	if !ok {
+		return
+	}
+	if value, err := number.Float64(); err == nil {
+		doc[key] = math.Round(convert(value)*100) / 100
+	}
+}
+
+// sendJSON writes a JSON fixture response
+func (s *Server) sendJSON(w http.ResponseWriter, data []byte) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+// sendError writes an error in the OpenWeatherMap error format
+func (s *Server) sendError(w http.ResponseWriter, statusCode int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"cod":     strconv.Itoa(statusCode),
+		"message": message,
+	})
+}
+
+// int64Field reads an integer field from a decoded JSON object
+func int64Field(doc map[string]interface{}, key string) (int64, bool) {
+	number, ok := doc[key].(json.Number)
+	if !ok {
+		return 0, false
+	}
+	value, err := number.Int64()
+	return value, err == nil
+}
+
+// shiftField adds delta seconds to an integer timestamp field
+func shiftField(doc map[string]interface{}, key string, delta int64) {
+	if value, ok := int64Field(doc, key); ok {
+		doc[key] = value + delta
+	}
+}
+
+// wholeDays rounds a delta down to whole days so sunrise and sunset keep their time of day
+func wholeDays(delta int64) int64 {
+	const day = 24 * 60 * 60
+	return delta / day * day
+}
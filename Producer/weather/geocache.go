@@ -0,0 +1,229 @@
+package weather
+
+import (
+	"container/list"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// GeoCache caches ZIP and city name to coordinate lookups in an in-memory LRU backed by an on-disk JSON file
+type GeoCache struct {
+	mu       sync.Mutex
+	capacity int
+	ttl      time.Duration
+	path     string
+	entries  map[string]*list.Element
+	order    *list.List // Front is most recently used
+	now      func() time.Time
+}
+
+// geoCacheEntry is a single cached lookup, also used as the on-disk record format
+type geoCacheEntry struct {
+	Zip      string    `json:"zip,omitempty"`
+	Country  string    `json:"country,omitempty"`
+	Name     string    `json:"name,omitempty"` // Set for city name lookups instead of Zip and Country
+	Lat      float64   `json:"lat"`
+	Lon      float64   `json:"lon"`
+	CachedAt time.Time `json:"cached_at"`
+}
+
+// NewGeoCache creates a new GeoCache instance.
+// A zero ttl keeps entries forever and an empty path disables persistence.
+func NewGeoCache(capacity int, ttl time.Duration, path string) *GeoCache {
+	if capacity < 1 {
+		capacity = 1
+	}
+
+	return &GeoCache{
+		capacity: capacity,
+		ttl:      ttl,
+		path:     path,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+		now:      time.Now,
+	}
+}
+
+// geoCacheKey normalizes a ZIP code and country into a cache key
+func geoCacheKey(zip, country string) string {
+	return strings.ToUpper(strings.TrimSpace(zip)) + "," + strings.ToUpper(strings.TrimSpace(country))
+}
+
+// geoCacheNameKey normalizes a city name into a cache key that cannot collide with ZIP keys
+func geoCacheNameKey(name string) string {
+	return "name:" + strings.ToUpper(strings.TrimSpace(name))
+}
+
+// key returns the cache key for an entry
+func (e geoCacheEntry) key() string {
+	if e.Name != "" {
+		return geoCacheNameKey(e.Name)
+	}
+	return geoCacheKey(e.Zip, e.Country)
+}
+
+// Load warms the cache from the on-disk store, skipping expired entries
+func (c *GeoCache) Load() error {
+	if c.path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(c.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read geocode cache: %w", err)
+	}
+
+	var stored []geoCacheEntry
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return fmt.Errorf("failed to parse geocode cache %s: %w", c.path, err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	loaded := 0
+	// Stored newest first, so insert in reverse to preserve recency order
+	for i := len(stored) - 1; i >= 0; i-- {
+		entry := stored[i]
+		if c.expired(entry) {
+			continue
+		}
+		c.insert(entry)
+		loaded++
+	}
+
+	log.Printf("🗺️ Loaded %d geocode cache entries from %s", loaded, c.path)
+	return nil
+}
+
+// Get returns cached coordinates for a ZIP code and country
+func (c *GeoCache) Get(zip, country string) (float64, float64, bool) {
+	return c.get(geoCacheKey(zip, country))
+}
+
+// GetName returns cached coordinates for a city name
+func (c *GeoCache) GetName(name string) (float64, float64, bool) {
+	return c.get(geoCacheNameKey(name))
+}
+
+// Put stores coordinates for a ZIP code and country and persists the cache
+func (c *GeoCache) Put(zip, country string, lat, lon float64) {
+	c.put(geoCacheEntry{
+		Zip:     strings.TrimSpace(zip),
+		Country: strings.ToUpper(strings.TrimSpace(country)),
+		Lat:     lat,
+		Lon:     lon,
+	})
+}
+
+// PutName stores coordinates for a city name and persists the cache
+func (c *GeoCache) PutName(name string, lat, lon float64) {
+	c.put(geoCacheEntry{
+		Name: strings.TrimSpace(name),
+		Lat:  lat,
+		Lon:  lon,
+	})
+}
+
+// get looks up an entry by key, dropping it when expired
+func (c *GeoCache) get(key string) (float64, float64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, exists := c.entries[key]
+	if !exists {
+		return 0, 0, false
+	}
+
+	entry := element.Value.(geoCacheEntry)
+	if c.expired(entry) {
+		c.order.Remove(element)
+		delete(c.entries, key)
+		return 0, 0, false
+	}
+
+	c.order.MoveToFront(element)
+	return entry.Lat, entry.Lon, true
+}
+
+// put stores an entry stamped with the current time and persists the cache
+func (c *GeoCache) put(entry geoCacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry.CachedAt = c.now()
+	c.insert(entry)
+
+	if err := c.save(); err != nil {
+		log.Printf("⚠️ Failed to persist geocode cache: %v", err)
+	}
+}
+
+// Len returns the number of cached entries
+func (c *GeoCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// insert adds or refreshes an entry and evicts the least recently used; callers must hold c.mu
+func (c *GeoCache) insert(entry geoCacheEntry) {
+	key := entry.key()
+	if element, exists := c.entries[key]; exists {
+		element.Value = entry
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(entry)
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		evicted := oldest.Value.(geoCacheEntry)
+		delete(c.entries, evicted.key())
+	}
+}
+
+// expired reports whether an entry is older than the TTL
+func (c *GeoCache) expired(entry geoCacheEntry) bool {
+	return c.ttl > 0 && c.now().Sub(entry.CachedAt) > c.ttl
+}
+
+// save writes the cache to disk atomically; callers must hold c.mu
+func (c *GeoCache) save() error {
+	if c.path == "" {
+		return nil
+	}
+
+	stored := make([]geoCacheEntry, 0, c.order.Len())
+	for element := c.order.Front(); element != nil; element = element.Next() {
+		stored = append(stored, element.Value.(geoCacheEntry))
+	}
+
+	data, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if dir := filepath.Dir(c.path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.path)
+}
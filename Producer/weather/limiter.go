@@ -0,0 +1,154 @@
+package weather
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/abhijeet1999/weather/models"
+)
+
+// RateLimiter is a token bucket shared by all weather API calls
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64 // Tokens added per second
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter creates a limiter allowing perMinute calls with bursts of up to burst calls
+func NewRateLimiter(perMinute, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &RateLimiter{
+		rate:   float64(perMinute) / 60,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Wait blocks until a token is available or the context is cancelled
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	for {
+		delay := l.reserve()
+		if delay <= 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// reserve takes a token if one is available, otherwise returns how long to wait for the next one
+func (l *RateLimiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.rate <= 0 {
+		return 0 // Unlimited
+	}
+
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0
+	}
+
+	return time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+}
+
+// QuotaTracker accounts weather API calls against a daily budget that resets at midnight UTC
+type QuotaTracker struct {
+	mu      sync.Mutex
+	limit   int     // Calls per day; zero disables the budget
+	reserve float64 // Fraction of the budget kept for non-low-priority locations
+	used    int
+	total   int
+	day     string
+	now     func() time.Time
+}
+
+// NewQuotaTracker creates a daily quota of limit calls, holding back the reserve
+// fraction (e.g. 0.1 = last 10%) for locations that are not low priority
+func NewQuotaTracker(limit int, reserve float64) *QuotaTracker {
+	return &QuotaTracker{
+		limit:   limit,
+		reserve: reserve,
+		now:     time.Now,
+	}
+}
+
+// rollover resets usage when the UTC day changes; callers must hold q.mu
+func (q *QuotaTracker) rollover() {
+	today := q.now().UTC().Format("2006-01-02")
+	if q.day != today {
+		q.day = today
+		q.used = 0
+	}
+}
+
+// Take consumes one call from the budget, returning false when the daily quota is exhausted
+func (q *QuotaTracker) Take() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.rollover()
+	if q.limit > 0 && q.used >= q.limit {
+		return false
+	}
+
+	q.used++
+	q.total++
+	return true
+}
+
+// Allows reports whether a location with the given priority may be polled.
+// Low-priority locations are deferred once usage enters the reserved part of the budget.
+func (q *QuotaTracker) Allows(priority string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.rollover()
+	if q.limit <= 0 {
+		return true
+	}
+	if q.used >= q.limit {
+		return false
+	}
+	if priority == models.PriorityLow {
+		return float64(q.used) < float64(q.limit)*(1-q.reserve)
+	}
+	return true
+}
+
+// Usage returns calls used today and the daily limit
+func (q *QuotaTracker) Usage() (int, int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.rollover()
+	return q.used, q.limit
+}
+
+// Total returns the number of calls made since startup
+func (q *QuotaTracker) Total() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.total
+}
@@ -0,0 +1,389 @@
+package weather
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/abhijeet1999/weather/Producer/utils"
+	"github.com/abhijeet1999/weather/models"
+)
+
+// openMeteoHourlyFields lists the hourly variables requested from Open-Meteo
+const openMeteoHourlyFields = "temperature_2m,relative_humidity_2m,apparent_temperature,pressure_msl,cloud_cover," +
+	"wind_speed_10m,wind_direction_10m,wind_gusts_10m,weather_code,precipitation_probability,visibility,is_day," +
+	"rain,showers,snowfall"
+
+// openMeteoCurrentFields lists the current variables requested from Open-Meteo
+const openMeteoCurrentFields = "temperature_2m,relative_humidity_2m,apparent_temperature,pressure_msl,cloud_cover," +
+	"wind_speed_10m,wind_direction_10m,wind_gusts_10m,weather_code,is_day,rain,showers,snowfall"
+
+// openMeteoForecastStep is the spacing of forecast items, matching the 3-hour OpenWeatherMap forecast
+const openMeteoForecastStep = 3
+
+// OpenMeteoProvider fetches weather data from the Open-Meteo API (no API key required)
+type OpenMeteoProvider struct {
+	client     *apiClient
+	baseURL    string
+	geoBaseURL string
+}
+
+// newOpenMeteoProvider creates a new OpenMeteoProvider instance
+func newOpenMeteoProvider(client *apiClient, baseURL, geoBaseURL string) *OpenMeteoProvider {
+	return &OpenMeteoProvider{
+		client:     client,
+		baseURL:    baseURL,
+		geoBaseURL: geoBaseURL,
+	}
+}
+
+// openMeteoResponse represents the response from the Open-Meteo Forecast API
+type openMeteoResponse struct {
+	Latitude         float64 `json:"latitude"`
+	Longitude        float64 `json:"longitude"`
+	UtcOffsetSeconds int     `json:"utc_offset_seconds"`
+	Current          struct {
+		Time                int64   `json:"time"`
+		Temperature         float64 `json:"temperature_2m"`
+		RelativeHumidity    float64 `json:"relative_humidity_2m"`
+		ApparentTemperature float64 `json:"apparent_temperature"`
+		PressureMsl         float64 `json:"pressure_msl"`
+		CloudCover          float64 `json:"cloud_cover"`
+		WindSpeed           float64 `json:"wind_speed_10m"`
+		WindDirection       float64 `json:"wind_direction_10m"`
+		WindGusts           float64 `json:"wind_gusts_10m"`
+		WeatherCode         int     `json:"weather_code"`
+		IsDay               int     `json:"is_day"`
+		Rain                float64 `json:"rain"`     // mm over the preceding hour
+		Showers             float64 `json:"showers"`  // mm over the preceding hour
+		Snowfall            float64 `json:"snowfall"` // cm over the preceding hour
+	} `json:"current"`
+	Hourly struct {
+		Time                     []int64   `json:"time"`
+		Temperature              []float64 `json:"temperature_2m"`
+		RelativeHumidity         []float64 `json:"relative_humidity_2m"`
+		ApparentTemperature      []float64 `json:"apparent_temperature"`
+		PressureMsl              []float64 `json:"pressure_msl"`
+		CloudCover               []float64 `json:"cloud_cover"`
+		WindSpeed                []float64 `json:"wind_speed_10m"`
+		WindDirection            []float64 `json:"wind_direction_10m"`
+		WindGusts                []float64 `json:"wind_gusts_10m"`
+		WeatherCode              []int     `json:"weather_code"`
+		PrecipitationProbability []float64 `json:"precipitation_probability"`
+		Visibility               []float64 `json:"visibility"`
+		IsDay                    []int     `json:"is_day"`
+		Rain                     []float64 `json:"rain"`
+		Showers                  []float64 `json:"showers"`
+		Snowfall                 []float64 `json:"snowfall"`
+	} `json:"hourly"`
+	Daily struct {
+		Sunrise []int64 `json:"sunrise"`
+		Sunset  []int64 `json:"sunset"`
+	} `json:"daily"`
+}
+
+// openMeteoGeoResponse represents the response from the Open-Meteo Geocoding API
+type openMeteoGeoResponse struct {
+	Results []struct {
+		Name        string  `json:"name"`
+		Latitude    float64 `json:"latitude"`
+		Longitude   float64 `json:"longitude"`
+		CountryCode string  `json:"country_code"`
+		Admin1      string  `json:"admin1"`
+	} `json:"results"`
+}
+
+// Name returns the provider name
+func (p *OpenMeteoProvider) Name() string {
+	return ProviderOpenMeteo
+}
+
+// GetCurrent fetches weather data by latitude and longitude
+func (p *OpenMeteoProvider) GetCurrent(ctx context.Context, lat, lon float64, units string) (models.OpenWeatherResponse, error) {
+	var weather models.OpenWeatherResponse
+	var resp openMeteoResponse
+
+	query := p.forecastQuery(lat, lon, units)
+	query.Set("current", openMeteoCurrentFields)
+	query.Set("daily", "sunrise,sunset")
+	query.Set("forecast_days", "1")
+
+	err := p.client.getJSON(ctx, "OpenMeteoRequest", p.baseURL+"/v1/forecast?"+query.Encode(), &resp)
+	if err != nil {
+		return weather, err
+	}
+
+	weather.Coord.Lat = resp.Latitude
+	weather.Coord.Lon = resp.Longitude
+	weather.Weather = []models.OpenWeatherCondition{openMeteoCondition(resp.Current.WeatherCode, resp.Current.IsDay == 1)}
+	weather.Base = "open-meteo"
+	weather.Main.Temp = float32(resp.Current.Temperature)
+	weather.Main.FeelsLike = float32(resp.Current.ApparentTemperature)
+	weather.Main.TempMin = float32(resp.Current.Temperature)
+	weather.Main.TempMax = float32(resp.Current.Temperature)
+	weather.Main.Pressure = int(resp.Current.PressureMsl)
+	weather.Main.Humidity = int(resp.Current.RelativeHumidity)
+	weather.Wind.Speed = float32(resp.Current.WindSpeed)
+	weather.Wind.Deg = int(resp.Current.WindDirection)
+	weather.Wind.Gust = float32(resp.Current.WindGusts)
+	weather.Rain = openMeteoPrecipitation(resp.Current.Rain+resp.Current.Showers, 0)
+	weather.Snow = openMeteoPrecipitation(resp.Current.Snowfall*10, 0) // cm to mm
+	weather.Clouds.All = int(resp.Current.CloudCover)
+	weather.Dt = resp.Current.Time
+	weather.Timezone = resp.UtcOffsetSeconds
+	if len(resp.Daily.Sunrise) > 0 && len(resp.Daily.Sunset) > 0 {
+		weather.Sys.Sunrise = resp.Daily.Sunrise[0]
+		weather.Sys.Sunset = resp.Daily.Sunset[0]
+	}
+	weather.Cod = 200
+
+	return weather, nil
+}
+
+// GetForecast fetches 5-day weather forecast by latitude and longitude, sampled every 3 hours
+func (p *OpenMeteoProvider) GetForecast(ctx context.Context, lat, lon float64, units string) (models.OpenWeatherForecastResponse, error) {
+	var forecast models.OpenWeatherForecastResponse
+	var resp openMeteoResponse
+
+	query := p.forecastQuery(lat, lon, units)
+	query.Set("hourly", openMeteoHourlyFields)
+	query.Set("forecast_days", "6")
+
+	err := p.client.getJSON(ctx, "OpenMeteoForecastRequest", p.baseURL+"/v1/forecast?"+query.Encode(), &resp)
+	if err != nil {
+		return forecast, err
+	}
+
+	h := resp.Hourly
+	now := time.Now().Unix()
+	for i, dt := range h.Time {
+		if len(forecast.List) >= 40 { // Match the 40 items (5 days x 8) returned by OpenWeatherMap
+			break
+		}
+		// Keep only future 3-hour steps, as OpenWeatherMap does
+		if dt <= now || time.Unix(dt, 0).UTC().Hour()%openMeteoForecastStep != 0 {
+			continue
+		}
+		if i >= len(h.Temperature) || i >= len(h.WeatherCode) {
+			break
+		}
+
+		var item models.ForecastItem
+		item.Dt = dt
+		item.Main.Temp = float32(h.Temperature[i])
+		item.Main.TempMin = float32(h.Temperature[i])
+		item.Main.TempMax = float32(h.Temperature[i])
+		item.Main.FeelsLike = float32(valueAt(h.ApparentTemperature, i))
+		item.Main.Pressure = int(valueAt(h.PressureMsl, i))
+		item.Main.Humidity = int(valueAt(h.RelativeHumidity, i))
+		isDay := i < len(h.IsDay) && h.IsDay[i] == 1
+		item.Weather = []models.OpenWeatherCondition{openMeteoCondition(h.WeatherCode[i], isDay)}
+		item.Clouds.All = int(valueAt(h.CloudCover, i))
+		item.Wind.Speed = float32(valueAt(h.WindSpeed, i))
+		item.Wind.Deg = int(valueAt(h.WindDirection, i))
+		item.Wind.Gust = float32(valueAt(h.WindGusts, i))
+		item.Rain = openMeteoPrecipitation(0, sumBefore(h.Rain, i, openMeteoForecastStep)+sumBefore(h.Showers, i, openMeteoForecastStep))
+		item.Snow = openMeteoPrecipitation(0, sumBefore(h.Snowfall, i, openMeteoForecastStep)*10) // cm to mm
+		item.Visibility = int(valueAt(h.Visibility, i))
+		if item.Visibility > 10000 {
+			item.Visibility = 10000
+		}
+		item.Pop = float32(valueAt(h.PrecipitationProbability, i) / 100)
+		item.Sys.Pod = "n"
+		if isDay {
+			item.Sys.Pod = "d"
+		}
+		item.DtTxt = time.Unix(dt, 0).UTC().Format("2006-01-02 15:04:05")
+
+		forecast.List = append(forecast.List, item)
+	}
+
+	forecast.Cod = "200"
+	forecast.Cnt = len(forecast.List)
+	forecast.City.Coord.Lat = resp.Latitude
+	forecast.City.Coord.Lon = resp.Longitude
+	forecast.City.Timezone = resp.UtcOffsetSeconds
+
+	return forecast, nil
+}
+
+// Geocode converts ZIP code to latitude and longitude coordinates
+func (p *OpenMeteoProvider) Geocode(ctx context.Context, zip, country string) (float64, float64, error) {
+	var geo openMeteoGeoResponse
+
+	query := url.Values{}
+	query.Set("name", zip)
+	query.Set("countryCode", country)
+	query.Set("count", "1")
+	query.Set("format", "json")
+
+	err := p.client.getJSON(ctx, "OpenMeteoGeoRequest", p.geoBaseURL+"/v1/search?"+query.Encode(), &geo)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get location: %w", err)
+	}
+
+	if len(geo.Results) == 0 {
+		return 0, 0, fmt.Errorf("failed to get location: %w", &APIError{
+			Kind:     ErrorKindNotFound,
+			Endpoint: "OpenMeteoGeoRequest",
+			Err:      fmt.Errorf("no results for %s,%s", zip, country),
+		})
+	}
+
+	return geo.Results[0].Latitude, geo.Results[0].Longitude, nil
+}
+
+// GeocodeName converts a "City[,State][,Country]" name to latitude and longitude coordinates.
+// Open-Meteo searches by city name only, so the state is matched against the first-level region of each result.
+func (p *OpenMeteoProvider) GeocodeName(ctx context.Context, name string) (float64, float64, error) {
+	var geo openMeteoGeoResponse
+
+	parts := strings.Split(name, ",")
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+
+	query := url.Values{}
+	query.Set("name", parts[0])
+	if len(parts) > 1 {
+		query.Set("countryCode", parts[len(parts)-1])
+	}
+	query.Set("count", "10")
+	query.Set("format", "json")
+
+	err := p.client.getJSON(ctx, "OpenMeteoGeoRequest", p.geoBaseURL+"/v1/search?"+query.Encode(), &geo)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get location: %w", err)
+	}
+
+	if len(geo.Results) == 0 {
+		return 0, 0, fmt.Errorf("failed to get location: %w", &APIError{
+			Kind:     ErrorKindNotFound,
+			Endpoint: "OpenMeteoGeoRequest",
+			Err:      fmt.Errorf("no results for %s", name),
+		})
+	}
+
+	best := geo.Results[0]
+	if len(parts) == 3 {
+		for _, result := range geo.Results {
+			if strings.EqualFold(result.Admin1, parts[1]) {
+				best = result
+				break
+			}
+		}
+	}
+
+	return best.Latitude, best.Longitude, nil
+}
+
+// forecastQuery builds the common Open-Meteo query parameters for a location and unit system
+func (p *OpenMeteoProvider) forecastQuery(lat, lon float64, units string) url.Values {
+	query := url.Values{}
+	query.Set("latitude", fmt.Sprintf("%f", lat))
+	query.Set("longitude", fmt.Sprintf("%f", lon))
+	query.Set("timezone", "auto")
+	query.Set("timeformat", "unixtime")
+
+	if units == utils.UnitImperial {
+		query.Set("temperature_unit", "fahrenheit")
+		query.Set("wind_speed_unit", "mph")
+	} else {
+		query.Set("temperature_unit", "celsius")
+		query.Set("wind_speed_unit", "ms")
+	}
+
+	return query
+}
+
+// valueAt returns the value at index i or zero when the series is shorter
+func valueAt(values []float64, i int) float64 {
+	if i < len(values) {
+		return values[i]
+	}
+	return 0
+}
+
+// sumBefore returns the sum of the n values ending at index i, e.g. the volume over the hours leading up to a slot
+func sumBefore(values []float64, i, n int) float64 {
+	var sum float64
+	for j := max(i-n+1, 0); j <= i; j++ {
+		sum += valueAt(values, j)
+	}
+	return sum
+}
+
+// openMeteoPrecipitation converts 1-hour and 3-hour volumes in mm to a Precipitation; like OpenWeatherMap,
+// it returns nil when there is none
+func openMeteoPrecipitation(oneHour, threeHours float64) *models.Precipitation {
+	if oneHour <= 0 && threeHours <= 0 {
+		return nil
+	}
+	return &models.Precipitation{OneHour: float32(oneHour), ThreeHours: float32(threeHours)}
+}
+
+// openMeteoCondition maps a WMO weather code to the equivalent OpenWeatherMap condition
+func openMeteoCondition(code int, isDay bool) models.OpenWeatherCondition {
+	var condition models.OpenWeatherCondition
+	var icon string
+
+	switch code {
+	case 0:
+		condition, icon = models.OpenWeatherCondition{Id: 800, Main: "Clear", Description: "clear sky"}, "01"
+	case 1:
+		condition, icon = models.OpenWeatherCondition{Id: 801, Main: "Clouds", Description: "few clouds"}, "02"
+	case 2:
+		condition, icon = models.OpenWeatherCondition{Id: 802, Main: "Clouds", Description: "scattered clouds"}, "03"
+	case 3:
+		condition, icon = models.OpenWeatherCondition{Id: 804, Main: "Clouds", Description: "overcast clouds"}, "04"
+	case 45, 48:
+		condition, icon = models.OpenWeatherCondition{Id: 741, Main: "Fog", Description: "fog"}, "50"
+	case 51:
+		condition, icon = models.OpenWeatherCondition{Id: 300, Main: "Drizzle", Description: "light intensity drizzle"}, "09"
+	case 53:
+		condition, icon = models.OpenWeatherCondition{Id: 301, Main: "Drizzle", Description: "drizzle"}, "09"
+	case 55:
+		condition, icon = models.OpenWeatherCondition{Id: 302, Main: "Drizzle", Description: "heavy intensity drizzle"}, "09"
+	case 56, 57, 66, 67:
+		condition, icon = models.OpenWeatherCondition{Id: 511, Main: "Rain", Description: "freezing rain"}, "13"
+	case 61:
+		condition, icon = models.OpenWeatherCondition{Id: 500, Main: "Rain", Description: "light rain"}, "10"
+	case 63:
+		condition, icon = models.OpenWeatherCondition{Id: 501, Main: "Rain", Description: "moderate rain"}, "10"
+	case 65:
+		condition, icon = models.OpenWeatherCondition{Id: 502, Main: "Rain", Description: "heavy intensity rain"}, "10"
+	case 71, 77:
+		condition, icon = models.OpenWeatherCondition{Id: 600, Main: "Snow", Description: "light snow"}, "13"
+	case 73:
+		condition, icon = models.OpenWeatherCondition{Id: 601, Main: "Snow", Description: "snow"}, "13"
+	case 75:
+		condition, icon = models.OpenWeatherCondition{Id: 602, Main: "Snow", Description: "heavy snow"}, "13"
+	case 80:
+		condition, icon = models.OpenWeatherCondition{Id: 520, Main: "Rain", Description: "light intensity shower rain"}, "09"
+	case 81:
+		condition, icon = models.OpenWeatherCondition{Id: 521, Main: "Rain", Description: "shower rain"}, "09"
+	case 82:
+		condition, icon = models.OpenWeatherCondition{Id: 522, Main: "Rain", Description: "heavy intensity shower rain"}, "09"
+	case 85:
+		condition, icon = models.OpenWeatherCondition{Id: 620, Main: "Snow", Description: "light shower snow"}, "13"
+	case 86:
+		condition, icon = models.OpenWeatherCondition{Id: 621, Main: "Snow", Description: "shower snow"}, "13"
+	case 95:
+		condition, icon = models.OpenWeatherCondition{Id: 211, Main: "Thunderstorm", Description: "thunderstorm"}, "11"
+	case 96:
+		condition, icon = models.OpenWeatherCondition{Id: 201, Main: "Thunderstorm", Description: "thunderstorm with rain"}, "11"
+	case 99:
+		condition, icon = models.OpenWeatherCondition{Id: 202, Main: "Thunderstorm", Description: "thunderstorm with heavy rain"}, "11"
+	default:
+		condition, icon = models.OpenWeatherCondition{Id: 800, Main: "Clear", Description: "unknown"}, "01"
+	}
+
+	if isDay {
+		condition.Icon = icon + "d"
+	} else {
+		condition.Icon = icon + "n"
+	}
+	return condition
+}
@@ -0,0 +1,96 @@
+package weather
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/abhijeet1999/weather/models"
+)
+
+// OpenWeatherMapProvider fetches weather data from the OpenWeatherMap API
+type OpenWeatherMapProvider struct {
+	client     *apiClient
+	baseURL    string
+	geoBaseURL string
+}
+
+// newOpenWeatherMapProvider creates a new OpenWeatherMapProvider instance
+func newOpenWeatherMapProvider(client *apiClient, baseURL, geoBaseURL string) *OpenWeatherMapProvider {
+	return &OpenWeatherMapProvider{
+		client:     client,
+		baseURL:    baseURL,
+		geoBaseURL: geoBaseURL,
+	}
+}
+
+// Name returns the provider name
+func (p *OpenWeatherMapProvider) Name() string {
+	return ProviderOpenWeatherMap
+}
+
+// GetCurrent fetches weather data by latitude and longitude
+func (p *OpenWeatherMapProvider) GetCurrent(ctx context.Context, lat, lon float64, units string) (models.OpenWeatherResponse, error) {
+	var weather models.OpenWeatherResponse
+
+	u := fmt.Sprintf(
+		"%s/data/2.5/weather?lat=%f&lon=%f&appid=%s&units=%s",
+		p.baseURL, lat, lon, p.client.apiKey(), units,
+	)
+
+	err := p.client.getJSON(ctx, "OpenWeatherRequest", u, &weather)
+	return weather, err
+}
+
+// GetForecast fetches 5-day weather forecast by latitude and longitude
+func (p *OpenWeatherMapProvider) GetForecast(ctx context.Context, lat, lon float64, units string) (models.OpenWeatherForecastResponse, error) {
+	var forecast models.OpenWeatherForecastResponse
+
+	u := fmt.Sprintf(
+		"%s/data/2.5/forecast?lat=%f&lon=%f&appid=%s&units=%s",
+		p.baseURL, lat, lon, p.client.apiKey(), units,
+	)
+
+	err := p.client.getJSON(ctx, "OpenWeatherForecastRequest", u, &forecast)
+	return forecast, err
+}
+
+// Geocode converts ZIP code to latitude and longitude coordinates
+func (p *OpenWeatherMapProvider) Geocode(ctx context.Context, zip, country string) (float64, float64, error) {
+	var geo models.GeoResponse
+
+	u := fmt.Sprintf(
+		"%s/geo/1.0/zip?zip=%s,%s&appid=%s",
+		p.geoBaseURL, url.QueryEscape(zip), url.QueryEscape(country), p.client.apiKey(),
+	)
+
+	if err := p.client.getJSON(ctx, "OpenWeatherGeoRequest", u, &geo); err != nil {
+		return 0, 0, fmt.Errorf("failed to get location: %w", err)
+	}
+
+	return geo.Lat, geo.Lon, nil
+}
+
+// GeocodeName converts a "City[,State][,Country]" name to latitude and longitude coordinates
+func (p *OpenWeatherMapProvider) GeocodeName(ctx context.Context, name string) (float64, float64, error) {
+	var results []models.GeoResponse
+
+	u := fmt.Sprintf(
+		"%s/geo/1.0/direct?q=%s&limit=1&appid=%s",
+		p.geoBaseURL, url.QueryEscape(name), p.client.apiKey(),
+	)
+
+	if err := p.client.getJSON(ctx, "OpenWeatherDirectGeoRequest", u, &results); err != nil {
+		return 0, 0, fmt.Errorf("failed to get location: %w", err)
+	}
+
+	if len(results) == 0 {
+		return 0, 0, fmt.Errorf("failed to get location: %w", &APIError{
+			Kind:     ErrorKindNotFound,
+			Endpoint: "OpenWeatherDirectGeoRequest",
+			Err:      fmt.Errorf("no results for %s", name),
+		})
+	}
+
+	return results[0].Lat, results[0].Lon, nil
+}
@@ -0,0 +1,136 @@
+package weather
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/abhijeet1999/weather/Producer/utils"
+)
+
+// Default provider base URLs
+const (
+	DefaultOpenWeatherMapBaseURL = "https://api.openweathermap.org"
+	DefaultOpenMeteoBaseURL      = "https://api.open-meteo.com"
+	DefaultOpenMeteoGeoBaseURL   = "https://geocoding-api.open-meteo.com"
+	DefaultUserAgent             = "weather-producer/1.0"
+)
+
+// Option configures a WeatherService
+type Option func(*serviceOptions)
+
+// serviceOptions holds the settings applied by Option functions
+type serviceOptions struct {
+	httpClient  *http.Client
+	apiKey      func() string
+	userAgent   string
+	baseURLs    map[string]string
+	geoBaseURLs map[string]string
+	retry       RetryPolicy
+	geoCache    *GeoCache
+	limiter     *RateLimiter
+	quota       *QuotaTracker
+	observer    RequestObserver
+}
+
+// WithHTTPClient sets the HTTP client used for all provider calls
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *serviceOptions) {
+		o.httpClient = client
+	}
+}
+
+// WithAPIKeySource sets the function used to look up the API key on every call
+func WithAPIKeySource(source func() string) Option {
+	return func(o *serviceOptions) {
+		o.apiKey = source
+	}
+}
+
+// WithAPIKey sets a fixed API key
+func WithAPIKey(key string) Option {
+	return WithAPIKeySource(func() string { return key })
+}
+
+// WithUserAgent sets the User-Agent header sent with every request
+func WithUserAgent(userAgent string) Option {
+	return func(o *serviceOptions) {
+		o.userAgent = userAgent
+	}
+}
+
+// WithRetryPolicy sets how transient failures (429, 5xx, network errors) are retried
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(o *serviceOptions) {
+		o.retry = policy
+	}
+}
+
+// WithGeoCache caches ZIP-to-coordinate lookups across all providers
+func WithGeoCache(cache *GeoCache) Option {
+	return func(o *serviceOptions) {
+		o.geoCache = cache
+	}
+}
+
+// WithRateLimiter paces all provider calls through a shared token bucket
+func WithRateLimiter(limiter *RateLimiter) Option {
+	return func(o *serviceOptions) {
+		o.limiter = limiter
+	}
+}
+
+// WithQuota accounts all provider calls against a daily budget
+func WithQuota(quota *QuotaTracker) Option {
+	return func(o *serviceOptions) {
+		o.quota = quota
+	}
+}
+
+// WithRequestObserver reports the latency and outcome of every provider request, e.g. to metrics
+func WithRequestObserver(observer RequestObserver) Option {
+	return func(o *serviceOptions) {
+		o.observer = observer
+	}
+}
+
+// WithBaseURL overrides the API base URL for a provider, e.g. to point at a fake server or caching proxy
+func WithBaseURL(provider, baseURL string) Option {
+	return func(o *serviceOptions) {
+		o.baseURLs[provider] = strings.TrimRight(baseURL, "/")
+	}
+}
+
+// WithGeoBaseURL overrides the geocoding base URL for a provider.
+// When unset, OpenWeatherMap geocoding uses the provider base URL.
+func WithGeoBaseURL(provider, baseURL string) Option {
+	return func(o *serviceOptions) {
+		o.geoBaseURLs[provider] = strings.TrimRight(baseURL, "/")
+	}
+}
+
+// defaultServiceOptions returns the options used when none are supplied
+func defaultServiceOptions() *serviceOptions {
+	return &serviceOptions{
+		apiKey:      utils.GetOpenWeatherMapApiKey,
+		userAgent:   DefaultUserAgent,
+		baseURLs:    make(map[string]string),
+		geoBaseURLs: make(map[string]string),
+		retry:       DefaultRetryPolicy,
+	}
+}
+
+// baseURL returns the configured base URL for a provider or the fallback
+func (o *serviceOptions) baseURL(provider, fallback string) string {
+	if u, exists := o.baseURLs[provider]; exists && u != "" {
+		return u
+	}
+	return fallback
+}
+
+// geoBaseURL returns the configured geocoding base URL for a provider or the fallback
+func (o *serviceOptions) geoBaseURL(provider, fallback string) string {
+	if u, exists := o.geoBaseURLs[provider]; exists && u != "" {
+		return u
+	}
+	return fallback
+}
@@ -0,0 +1,28 @@
+package weather
+
+import (
+	"context"
+
+	"github.com/abhijeet1999/weather/models"
+)
+
+// Supported weather provider names
+const (
+	ProviderOpenWeatherMap = "openweathermap"
+	ProviderOpenMeteo      = "openmeteo"
+)
+
+// WeatherProvider fetches weather data from a specific vendor API.
+// Adapters normalize their responses into the OpenWeatherMap models used by the rest of the pipeline.
+type WeatherProvider interface {
+	// Name returns the provider name used to select it per location
+	Name() string
+	// GetCurrent fetches current weather by latitude and longitude
+	GetCurrent(ctx context.Context, lat, lon float64, units string) (models.OpenWeatherResponse, error)
+	// GetForecast fetches a 5-day forecast in 3-hour steps by latitude and longitude
+	GetForecast(ctx context.Context, lat, lon float64, units string) (models.OpenWeatherForecastResponse, error)
+	// Geocode converts a ZIP code and country to latitude and longitude coordinates
+	Geocode(ctx context.Context, zip, country string) (float64, float64, error)
+	// GeocodeName converts a "City[,State][,Country]" name to latitude and longitude coordinates
+	GeocodeName(ctx context.Context, name string) (float64, float64, error)
+}
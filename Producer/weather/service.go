@@ -1,118 +1,213 @@
 package weather
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/abhijeet1999/weather/models"
-	"github.com/abhijeet1999/weather/Producer/utils"
 )
 
-// WeatherService handles weather-related API calls
+// WeatherService handles weather-related API calls through a WeatherProvider
 type WeatherService struct {
-	httpClient http.Client
+	providers map[string]WeatherProvider
+	provider  WeatherProvider
+	geoCache  *GeoCache
+	quota     *QuotaTracker
 }
 
 // NewWeatherService creates a new WeatherService instance
-func NewWeatherService() *WeatherService {
-	return &WeatherService{
-		httpClient: http.Client{
+func NewWeatherService(opts ...Option) *WeatherService {
+	options := defaultServiceOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	httpClient := options.httpClient
+	if httpClient == nil {
+		httpClient = &http.Client{
 			Timeout: time.Second * 10,
-		},
+		}
+	}
+
+	client := &apiClient{
+		httpClient: httpClient,
+		apiKey:     options.apiKey,
+		userAgent:  options.userAgent,
+		retry:      options.retry,
+		limiter:    options.limiter,
+		quota:      options.quota,
+		observe:    options.observer,
 	}
+
+	owmBaseURL := options.baseURL(ProviderOpenWeatherMap, DefaultOpenWeatherMapBaseURL)
+
+	ws := &WeatherService{
+		providers: make(map[string]WeatherProvider),
+		geoCache:  options.geoCache,
+		quota:     options.quota,
+	}
+	ws.RegisterProvider(newOpenWeatherMapProvider(
+		client,
+		owmBaseURL,
+		options.geoBaseURL(ProviderOpenWeatherMap, owmBaseURL),
+	))
+	ws.RegisterProvider(newOpenMeteoProvider(
+		client,
+		options.baseURL(ProviderOpenMeteo, DefaultOpenMeteoBaseURL),
+		options.geoBaseURL(ProviderOpenMeteo, DefaultOpenMeteoGeoBaseURL),
+	))
+	ws.provider = ws.providers[ProviderOpenWeatherMap]
+
+	return ws
 }
 
-// GetWeather fetches weather data by latitude and longitude
-func (ws *WeatherService) GetWeather(lat, lon float64, units string) (models.OpenWeatherResponse, error) {
-	var weather models.OpenWeatherResponse
+// RegisterProvider adds or replaces a provider adapter
+func (ws *WeatherService) RegisterProvider(provider WeatherProvider) {
+	ws.providers[provider.Name()] = provider
+}
 
-	u := fmt.Sprintf(
-		"https://api.openweathermap.org/data/2.5/weather?lat=%f&lon=%f&appid=%s&units=%s",
-		lat, lon, utils.GetOpenWeatherMapApiKey(), units,
-	)
+// SetDefaultProvider selects the provider used when a location does not specify one
+func (ws *WeatherService) SetDefaultProvider(name string) error {
+	provider, exists := ws.providers[name]
+	if !exists {
+		return fmt.Errorf("unknown weather provider '%s'", name)
+	}
 
-	r, err := ws.httpClient.Get(u)
-	if err != nil {
-		return weather, err
+	ws.provider = provider
+	return nil
+}
+
+// WithProvider returns a WeatherService that routes calls through the named provider.
+// An empty name keeps the default provider.
+func (ws *WeatherService) WithProvider(name string) (*WeatherService, error) {
+	if name == "" || name == ws.provider.Name() {
+		return ws, nil
 	}
-	defer r.Body.Close()
 
-	if r.StatusCode != 200 {
-		return weather, fmt.Errorf("OpenWeatherRequest Failed: %s", r.Status)
+	provider, exists := ws.providers[name]
+	if !exists {
+		return nil, fmt.Errorf("unknown weather provider '%s'", name)
 	}
 
-	err = json.NewDecoder(r.Body).Decode(&weather)
-	return weather, err
+	scoped := *ws
+	scoped.provider = provider
+	return &scoped, nil
 }
 
-// GetLatLon converts ZIP code to latitude and longitude coordinates
-func (ws *WeatherService) GetLatLon(zip, country string) (float64, float64, error) {
-	var geo models.GeoResponse
+// ProviderName returns the name of the provider used by this service
+func (ws *WeatherService) ProviderName() string {
+	return ws.provider.Name()
+}
 
-	url := fmt.Sprintf(
-		"http://api.openweathermap.org/geo/1.0/zip?zip=%s,%s&appid=%s",
-		zip, country, utils.GetOpenWeatherMapApiKey(),
-	)
+// AllowsPriority reports whether a location with the given priority may be polled under the daily quota
+func (ws *WeatherService) AllowsPriority(priority string) bool {
+	if ws.quota == nil {
+		return true
+	}
+	return ws.quota.Allows(priority)
+}
 
-	resp, err := ws.httpClient.Get(url)
+// GetWeather fetches weather data by latitude and longitude
+func (ws *WeatherService) GetWeather(ctx context.Context, lat, lon float64, units string) (models.OpenWeatherResponse, error) {
+	return ws.provider.GetCurrent(ctx, lat, lon, units)
+}
+
+// GetLatLon converts ZIP code to latitude and longitude coordinates, using the geocode cache when configured
+func (ws *WeatherService) GetLatLon(ctx context.Context, zip, country string) (float64, float64, error) {
+	if ws.geoCache != nil {
+		if lat, lon, ok := ws.geoCache.Get(zip, country); ok {
+			return lat, lon, nil
+		}
+	}
+
+	lat, lon, err := ws.provider.Geocode(ctx, zip, country)
 	if err != nil {
 		return 0, 0, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != 200 {
-		return 0, 0, fmt.Errorf("failed to get location: %s", resp.Status)
+	if ws.geoCache != nil {
+		ws.geoCache.Put(zip, country, lat, lon)
 	}
 
-	err = json.NewDecoder(resp.Body).Decode(&geo)
+	return lat, lon, nil
+}
+
+// GetLatLonByName converts a "City[,State][,Country]" name to latitude and longitude coordinates,
+// using the geocode cache when configured
+func (ws *WeatherService) GetLatLonByName(ctx context.Context, name string) (float64, float64, error) {
+	if ws.geoCache != nil {
+		if lat, lon, ok := ws.geoCache.GetName(name); ok {
+			return lat, lon, nil
+		}
+	}
+
+	lat, lon, err := ws.provider.GeocodeName(ctx, name)
 	if err != nil {
 		return 0, 0, err
 	}
 
-	return geo.Lat, geo.Lon, nil
+	if ws.geoCache != nil {
+		ws.geoCache.PutName(name, lat, lon)
+	}
+
+	return lat, lon, nil
+}
+
+// ResolveLocation returns the coordinates of a request given by ZIP code, city name or latitude and longitude
+func (ws *WeatherService) ResolveLocation(ctx context.Context, req models.WeatherRequest) (float64, float64, error) {
+	switch {
+	case req.HasCoordinates:
+		return req.Lat, req.Lon, nil
+	case req.City != "":
+		return ws.GetLatLonByName(ctx, req.City)
+	default:
+		return ws.GetLatLon(ctx, req.ZipCode, req.Country)
+	}
 }
 
 // GetWeatherByZip fetches weather data by ZIP code and country
-func (ws *WeatherService) GetWeatherByZip(zip, country, units string) (models.OpenWeatherResponse, error) {
-	lat, lon, err := ws.GetLatLon(zip, country)
+func (ws *WeatherService) GetWeatherByZip(ctx context.Context, zip, country, units string) (models.OpenWeatherResponse, error) {
+	lat, lon, err := ws.GetLatLon(ctx, zip, country)
 	if err != nil {
 		return models.OpenWeatherResponse{}, err
 	}
 
-	return ws.GetWeather(lat, lon, units)
+	return ws.GetWeather(ctx, lat, lon, units)
 }
 
-// GetForecast fetches 5-day weather forecast by latitude and longitude
-func (ws *WeatherService) GetForecast(lat, lon float64, units string) (models.OpenWeatherForecastResponse, error) {
-	var forecast models.OpenWeatherForecastResponse
-
-	u := fmt.Sprintf(
-		"https://api.openweathermap.org/data/2.5/forecast?lat=%f&lon=%f&appid=%s&units=%s",
-		lat, lon, utils.GetOpenWeatherMapApiKey(), units,
-	)
-
-	r, err := ws.httpClient.Get(u)
+// GetWeatherForLocation fetches weather data for a request's ZIP code, city name or coordinates
+func (ws *WeatherService) GetWeatherForLocation(ctx context.Context, req models.WeatherRequest, units string) (models.OpenWeatherResponse, error) {
+	lat, lon, err := ws.ResolveLocation(ctx, req)
 	if err != nil {
-		return forecast, err
+		return models.OpenWeatherResponse{}, err
 	}
-	defer r.Body.Close()
 
-	if r.StatusCode != 200 {
-		return forecast, fmt.Errorf("OpenWeatherForecastRequest Failed: %s", r.Status)
-	}
+	return ws.GetWeather(ctx, lat, lon, units)
+}
 
-	err = json.NewDecoder(r.Body).Decode(&forecast)
-	return forecast, err
+// GetForecast fetches 5-day weather forecast by latitude and longitude
+func (ws *WeatherService) GetForecast(ctx context.Context, lat, lon float64, units string) (models.OpenWeatherForecastResponse, error) {
+	return ws.provider.GetForecast(ctx, lat, lon, units)
 }
 
 // GetForecastByZip fetches 5-day weather forecast by ZIP code and country
-func (ws *WeatherService) GetForecastByZip(zip, country, units string) (models.OpenWeatherForecastResponse, error) {
-	lat, lon, err := ws.GetLatLon(zip, country)
+func (ws *WeatherService) GetForecastByZip(ctx context.Context, zip, country, units string) (models.OpenWeatherForecastResponse, error) {
+	lat, lon, err := ws.GetLatLon(ctx, zip, country)
+	if err != nil {
+		return models.OpenWeatherForecastResponse{}, err
+	}
+
+	return ws.GetForecast(ctx, lat, lon, units)
+}
+
+// GetForecastForLocation fetches 5-day weather forecast for a request's ZIP code, city name or coordinates
+func (ws *WeatherService) GetForecastForLocation(ctx context.Context, req models.WeatherRequest, units string) (models.OpenWeatherForecastResponse, error) {
+	lat, lon, err := ws.ResolveLocation(ctx, req)
 	if err != nil {
 		return models.OpenWeatherForecastResponse{}, err
 	}
 
-	return ws.GetForecast(lat, lon, units)
+	return ws.GetForecast(ctx, lat, lon, units)
 }
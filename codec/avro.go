@@ -0,0 +1,574 @@
+package codec
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/abhijeet1999/weather/models"
+)
+
+// avroCodec encodes messages as Avro binary with the weather.v1.WeatherMessage record in schemas/weather_message_v1_4.avsc.
+// Avro data carries no field tags, so readers rely on the schema ID header to know the writer's schema.
+// Fields added in a minor version are appended to the end of the record: the leading schema_version tells the
+// reader which of them are present, and trailing fields from newer minor versions are ignored.
+type avroCodec struct{}
+
+// Name returns the codec name
+func (avroCodec) Name() string {
+	return NameAvro
+}
+
+// Marshal encodes a message as Avro binary
+func (avroCodec) Marshal(msg models.WeatherMessage) ([]byte, error) {
+	var w avroWriter
+	w.string(msg.SchemaVersion)
+	w.long(msg.Timestamp.UnixMilli())
+	w.string(msg.ZipCode)
+	w.string(msg.City)
+	w.string(msg.Country)
+	w.string(msg.Units)
+	w.string(msg.MessageType)
+
+	if w.optional(msg.Current != nil) {
+		writeAvroCurrent(&w, *msg.Current)
+	}
+	if w.optional(msg.Forecast != nil) {
+		writeAvroForecast(&w, *msg.Forecast)
+	}
+	if w.optional(msg.Hourly != nil) {
+		writeAvroForecastItem(&w, *msg.Hourly)
+	}
+	if w.optional(msg.Daily != nil) {
+		writeAvroDaily(&w, *msg.Daily)
+	}
+
+	// Added in 1.1
+	w.string(msg.MessageID)
+
+	// Added in 1.2
+	w.string(msg.Timezone)
+	w.int(msg.UTCOffset)
+
+	// Added in 1.3
+	if w.optional(msg.Daily != nil) {
+		writeAvroDailyDetails(&w, *msg.Daily)
+	}
+
+	// Added in 1.4
+	if w.optional(msg.Current != nil) {
+		writeAvroWeatherDetails(&w, msg.Current.Wind.Gust, msg.Current.Rain, msg.Current.Snow)
+	}
+	if w.optional(msg.Forecast != nil) {
+		w.arrayBlock(len(msg.Forecast.List))
+		for _, item := range msg.Forecast.List {
+			writeAvroWeatherDetails(&w, item.Wind.Gust, item.Rain, item.Snow)
+		}
+		w.arrayEnd()
+	}
+	if w.optional(msg.Hourly != nil) {
+		writeAvroWeatherDetails(&w, msg.Hourly.Wind.Gust, msg.Hourly.Rain, msg.Hourly.Snow)
+	}
+	return w.b, nil
+}
+
+// Unmarshal decodes an Avro binary message
+func (avroCodec) Unmarshal(data []byte, msg *models.WeatherMessage) error {
+	r := avroReader{b: data}
+	msg.SchemaVersion = r.string()
+	msg.Timestamp = time.UnixMilli(r.long()).UTC()
+	msg.ZipCode = r.string()
+	msg.City = r.string()
+	msg.Country = r.string()
+	msg.Units = r.string()
+	msg.MessageType = r.string()
+
+	if r.optional() {
+		var current models.OpenWeatherResponse
+		readAvroCurrent(&r, &current)
+		msg.Current = &current
+	}
+	if r.optional() {
+		var forecast models.OpenWeatherForecastResponse
+		readAvroForecast(&r, &forecast)
+		msg.Forecast = &forecast
+	}
+	if r.optional() {
+		var hourly models.ForecastItem
+		readAvroForecastItem(&r, &hourly)
+		msg.Hourly = &hourly
+	}
+	if r.optional() {
+		var daily models.DailyWeatherData
+		readAvroDaily(&r, &daily)
+		msg.Daily = &daily
+	}
+
+	// Fields appended after 1.0, present only when the writer's schema has them
+	_, minor, err := models.ParseSchemaVersion(msg.SchemaVersion)
+	if err != nil {
+		minor = 0
+	}
+	if minor >= 1 {
+		msg.MessageID = r.string()
+	}
+	if minor >= 2 {
+		msg.Timezone = r.string()
+		msg.UTCOffset = r.int()
+	}
+	if minor >= 3 && r.optional() {
+		daily := msg.Daily
+		if daily == nil {
+			daily = &models.DailyWeatherData{} // Written only alongside daily; read to keep the position
+		}
+		readAvroDailyDetails(&r, daily)
+	}
+	if minor >= 4 {
+		readAvroDetails(&r, msg)
+	}
+
+	if r.err == nil && len(r.b) > 0 && minor <= models.MessageSchemaMinor {
+		r.err = fmt.Errorf("%d unexpected trailing bytes", len(r.b))
+	}
+	if r.err != nil {
+		return fmt.Errorf("invalid avro message: %v", r.err)
+	}
+	return nil
+}
+
+// writeAvroCurrent encodes a weather.v1.CurrentWeather record
+func writeAvroCurrent(w *avroWriter, current models.OpenWeatherResponse) {
+	w.double(current.Coord.Lat)
+	w.double(current.Coord.Lon)
+	writeAvroConditions(w, current.Weather)
+	w.float(current.Main.Temp)
+	w.float(current.Main.FeelsLike)
+	w.float(current.Main.TempMin)
+	w.float(current.Main.TempMax)
+	w.int(current.Main.Pressure)
+	w.int(current.Main.Humidity)
+	w.int(current.Visibility)
+	w.float(current.Wind.Speed)
+	w.int(current.Wind.Deg)
+	w.int(current.Clouds.All)
+	w.long(current.Dt)
+	w.string(current.Sys.Country)
+	w.long(current.Sys.Sunrise)
+	w.long(current.Sys.Sunset)
+	w.int(current.Timezone)
+	w.int(current.Id)
+	w.string(current.Name)
+}
+
+// readAvroCurrent decodes a weather.v1.CurrentWeather record
+func readAvroCurrent(r *avroReader, current *models.OpenWeatherResponse) {
+	current.Coord.Lat = r.double()
+	current.Coord.Lon = r.double()
+	current.Weather = readAvroConditions(r)
+	current.Main.Temp = r.float()
+	current.Main.FeelsLike = r.float()
+	current.Main.TempMin = r.float()
+	current.Main.TempMax = r.float()
+	current.Main.Pressure = r.int()
+	current.Main.Humidity = r.int()
+	current.Visibility = r.int()
+	current.Wind.Speed = r.float()
+	current.Wind.Deg = r.int()
+	current.Clouds.All = r.int()
+	current.Dt = r.long()
+	current.Sys.Country = r.string()
+	current.Sys.Sunrise = r.long()
+	current.Sys.Sunset = r.long()
+	current.Timezone = r.int()
+	current.Id = r.int()
+	current.Name = r.string()
+}
+
+// writeAvroForecast encodes a weather.v1.Forecast record
+func writeAvroForecast(w *avroWriter, forecast models.OpenWeatherForecastResponse) {
+	w.int(forecast.City.Id)
+	w.string(forecast.City.Name)
+	w.double(forecast.City.Coord.Lat)
+	w.double(forecast.City.Coord.Lon)
+	w.string(forecast.City.Country)
+	w.int(forecast.City.Timezone)
+	w.long(forecast.City.Sunrise)
+	w.long(forecast.City.Sunset)
+
+	w.arrayBlock(len(forecast.List))
+	for _, item := range forecast.List {
+		writeAvroForecastItem(w, item)
+	}
+	w.arrayEnd()
+}
+
+// readAvroForecast decodes a weather.v1.Forecast record
+func readAvroForecast(r *avroReader, forecast *models.OpenWeatherForecastResponse) {
+	forecast.City.Id = r.int()
+	forecast.City.Name = r.string()
+	forecast.City.Coord.Lat = r.double()
+	forecast.City.Coord.Lon = r.double()
+	forecast.City.Country = r.string()
+	forecast.City.Timezone = r.int()
+	forecast.City.Sunrise = r.long()
+	forecast.City.Sunset = r.long()
+
+	r.array(func() {
+		var item models.ForecastItem
+		readAvroForecastItem(r, &item)
+		forecast.List = append(forecast.List, item)
+	})
+	forecast.Cnt = len(forecast.List)
+}
+
+// writeAvroForecastItem encodes a weather.v1.ForecastItem record
+func writeAvroForecastItem(w *avroWriter, item models.ForecastItem) {
+	w.long(item.Dt)
+	w.float(item.Main.Temp)
+	w.float(item.Main.FeelsLike)
+	w.float(item.Main.TempMin)
+	w.float(item.Main.TempMax)
+	w.int(item.Main.Pressure)
+	w.int(item.Main.Humidity)
+	writeAvroConditions(w, item.Weather)
+	w.int(item.Clouds.All)
+	w.float(item.Wind.Speed)
+	w.int(item.Wind.Deg)
+	w.int(item.Visibility)
+	w.float(item.Pop)
+	w.string(item.Sys.Pod)
+	w.string(item.DtTxt)
+}
+
+// readAvroForecastItem decodes a weather.v1.ForecastItem record
+func readAvroForecastItem(r *avroReader, item *models.ForecastItem) {
+	item.Dt = r.long()
+	item.Main.Temp = r.float()
+	item.Main.FeelsLike = r.float()
+	item.Main.TempMin = r.float()
+	item.Main.TempMax = r.float()
+	item.Main.Pressure = r.int()
+	item.Main.Humidity = r.int()
+	item.Weather = readAvroConditions(r)
+	item.Clouds.All = r.int()
+	item.Wind.Speed = r.float()
+	item.Wind.Deg = r.int()
+	item.Visibility = r.int()
+	item.Pop = r.float()
+	item.Sys.Pod = r.string()
+	item.DtTxt = r.string()
+}
+
+// writeAvroConditions encodes an array of weather.v1.Condition records
+func writeAvroConditions(w *avroWriter, conditions []models.OpenWeatherCondition) {
+	w.arrayBlock(len(conditions))
+	for _, condition := range conditions {
+		w.int(condition.Id)
+		w.string(condition.Main)
+		w.string(condition.Description)
+		w.string(condition.Icon)
+	}
+	w.arrayEnd()
+}
+
+// readAvroConditions decodes an array of weather.v1.Condition records
+func readAvroConditions(r *avroReader) []models.OpenWeatherCondition {
+	var conditions []models.OpenWeatherCondition
+	r.array(func() {
+		conditions = append(conditions, models.OpenWeatherCondition{
+			Id:          r.int(),
+			Main:        r.string(),
+			Description: r.string(),
+			Icon:        r.string(),
+		})
+	})
+	return conditions
+}
+
+// writeAvroDaily encodes a weather.v1.DailyWeather record
+func writeAvroDaily(w *avroWriter, daily models.DailyWeatherData) {
+	w.int(daily.Day)
+	w.string(daily.Date)
+	w.float(daily.TempMin)
+	w.float(daily.TempMax)
+	w.float(daily.TempAvg)
+	w.int(daily.Humidity)
+	w.float(daily.WindSpeed)
+	w.string(daily.Description)
+	w.string(daily.Icon)
+}
+
+// readAvroDaily decodes a weather.v1.DailyWeather record
+func readAvroDaily(r *avroReader, daily *models.DailyWeatherData) {
+	daily.Day = r.int()
+	daily.Date = r.string()
+	daily.TempMin = r.float()
+	daily.TempMax = r.float()
+	daily.TempAvg = r.float()
+	daily.Humidity = r.int()
+	daily.WindSpeed = r.float()
+	daily.Description = r.string()
+	daily.Icon = r.string()
+}
+
+// writeAvroDailyDetails encodes a weather.v1.DailyDetails record, the daily fields added in 1.3
+func writeAvroDailyDetails(w *avroWriter, daily models.DailyWeatherData) {
+	w.float(daily.FeelsLikeMin)
+	w.float(daily.FeelsLikeMax)
+	w.float(daily.WindGust)
+	w.int(daily.Clouds)
+	w.float(daily.Pop)
+	w.float(daily.Rain)
+	w.float(daily.Snow)
+}
+
+// readAvroDailyDetails decodes a weather.v1.DailyDetails record into the daily fields added in 1.3
+func readAvroDailyDetails(r *avroReader, daily *models.DailyWeatherData) {
+	daily.FeelsLikeMin = r.float()
+	daily.FeelsLikeMax = r.float()
+	daily.WindGust = r.float()
+	daily.Clouds = r.int()
+	daily.Pop = r.float()
+	daily.Rain = r.float()
+	daily.Snow = r.float()
+}
+
+// readAvroDetails decodes the current, forecast and hourly details added in 1.4 into the records read before them.
+// Details are written only alongside their record; they are read regardless to keep the position.
+func readAvroDetails(r *avroReader, msg *models.WeatherMessage) {
+	if r.optional() {
+		current := msg.Current
+		if current == nil {
+			current = &models.OpenWeatherResponse{}
+		}
+		readAvroWeatherDetails(r, &current.Wind.Gust, &current.Rain, &current.Snow)
+	}
+	if r.optional() {
+		var list []models.ForecastItem
+		if msg.Forecast != nil {
+			list = msg.Forecast.List
+		}
+		count := 0
+		r.array(func() {
+			item := &models.ForecastItem{}
+			if count < len(list) {
+				item = &list[count]
+			}
+			readAvroWeatherDetails(r, &item.Wind.Gust, &item.Rain, &item.Snow)
+			count++
+		})
+		if r.err == nil && count != len(list) {
+			r.err = fmt.Errorf("%d forecast details for %d forecast items", count, len(list))
+		}
+	}
+	if r.optional() {
+		hourly := msg.Hourly
+		if hourly == nil {
+			hourly = &models.ForecastItem{}
+		}
+		readAvroWeatherDetails(r, &hourly.Wind.Gust, &hourly.Rain, &hourly.Snow)
+	}
+}
+
+// writeAvroWeatherDetails encodes a weather.v1.WeatherDetails record, the current and forecast item fields added in 1.4
+func writeAvroWeatherDetails(w *avroWriter, gust float32, rain, snow *models.Precipitation) {
+	w.float(gust)
+	if w.optional(rain != nil) {
+		writeAvroPrecipitation(w, *rain)
+	}
+	if w.optional(snow != nil) {
+		writeAvroPrecipitation(w, *snow)
+	}
+}
+
+// readAvroWeatherDetails decodes a weather.v1.WeatherDetails record
+func readAvroWeatherDetails(r *avroReader, gust *float32, rain, snow **models.Precipitation) {
+	*gust = r.float()
+	if r.optional() {
+		*rain = readAvroPrecipitation(r)
+	}
+	if r.optional() {
+		*snow = readAvroPrecipitation(r)
+	}
+}
+
+// writeAvroPrecipitation encodes a weather.v1.Precipitation record
+func writeAvroPrecipitation(w *avroWriter, precipitation models.Precipitation) {
+	w.float(precipitation.OneHour)
+	w.float(precipitation.ThreeHours)
+}
+
+// readAvroPrecipitation decodes a weather.v1.Precipitation record
+func readAvroPrecipitation(r *avroReader) *models.Precipitation {
+	return &models.Precipitation{
+		OneHour:    r.float(),
+		ThreeHours: r.float(),
+	}
+}
+
+// avroWriter appends Avro binary values
+type avroWriter struct {
+	b []byte
+}
+
+// long appends a zig-zag varint, the Avro encoding of int and long
+func (w *avroWriter) long(v int64) {
+	w.b = binary.AppendVarint(w.b, v)
+}
+
+// int appends an Avro int
+func (w *avroWriter) int(v int) {
+	w.long(int64(v))
+}
+
+// float appends an Avro float
+func (w *avroWriter) float(v float32) {
+	w.b = binary.LittleEndian.AppendUint32(w.b, math.Float32bits(v))
+}
+
+// double appends an Avro double
+func (w *avroWriter) double(v float64) {
+	w.b = binary.LittleEndian.AppendUint64(w.b, math.Float64bits(v))
+}
+
+// string appends an Avro string
+func (w *avroWriter) string(v string) {
+	w.long(int64(len(v)))
+	w.b = append(w.b, v...)
+}
+
+// optional appends the branch index of a ["null", T] union and reports whether the value follows
+func (w *avroWriter) optional(present bool) bool {
+	if present {
+		w.long(1)
+	} else {
+		w.long(0)
+	}
+	return present
+}
+
+// arrayBlock starts an array written as a single block of n items
+func (w *avroWriter) arrayBlock(n int) {
+	if n > 0 {
+		w.long(int64(n))
+	}
+}
+
+// arrayEnd terminates an array
+func (w *avroWriter) arrayEnd() {
+	w.long(0)
+}
+
+// avroReader decodes Avro binary values, recording the first error
+type avroReader struct {
+	b   []byte
+	err error
+}
+
+// errAvroTruncated is reported when the data ends before the schema does
+var errAvroTruncated = errors.New("unexpected end of data")
+
+// long reads a zig-zag varint
+func (r *avroReader) long() int64 {
+	if r.err != nil {
+		return 0
+	}
+
+	v, n := binary.Varint(r.b)
+	if n <= 0 {
+		r.err = errAvroTruncated
+		return 0
+	}
+	r.b = r.b[n:]
+	return v
+}
+
+// int reads an Avro int
+func (r *avroReader) int() int {
+	v := r.long()
+	if v < math.MinInt32 || v > math.MaxInt32 {
+		r.err = fmt.Errorf("int value %d out of range", v)
+		return 0
+	}
+	return int(v)
+}
+
+// float reads an Avro float
+func (r *avroReader) float() float32 {
+	if r.err != nil {
+		return 0
+	}
+	if len(r.b) < 4 {
+		r.err = errAvroTruncated
+		return 0
+	}
+
+	v := math.Float32frombits(binary.LittleEndian.Uint32(r.b))
+	r.b = r.b[4:]
+	return v
+}
+
+// double reads an Avro double
+func (r *avroReader) double() float64 {
+	if r.err != nil {
+		return 0
+	}
+	if len(r.b) < 8 {
+		r.err = errAvroTruncated
+		return 0
+	}
+
+	v := math.Float64frombits(binary.LittleEndian.Uint64(r.b))
+	r.b = r.b[8:]
+	return v
+}
+
+// string reads an Avro string
+func (r *avroReader) string() string {
+	n := r.long()
+	if r.err != nil {
+		return ""
+	}
+	if n < 0 || n > int64(len(r.b)) {
+		r.err = errAvroTruncated
+		return ""
+	}
+
+	v := string(r.b[:n])
+	r.b = r.b[n:]
+	return v
+}
+
+// optional reads the branch index of a ["null", T] union and reports whether a value follows
+func (r *avroReader) optional() bool {
+	switch index := r.long(); index {
+	case 0:
+		return false
+	case 1:
+		return r.err == nil
+	default:
+		r.err = fmt.Errorf("invalid union branch %d", index)
+		return false
+	}
+}
+
+// array calls item once per array element, across however many blocks the writer used
+func (r *avroReader) array(item func()) {
+	for r.err == nil {
+		count := r.long()
+		if count == 0 || r.err != nil {
+			return
+		}
+		if count < 0 {
+			// A negative count is followed by the block size in bytes
+			count = -count
+			r.long()
+		}
+
+		for i := int64(0); i < count && r.err == nil; i++ {
+			item()
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package codec
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/abhijeet1999/weather/models"
+)
+
+// Codec names, advertised in the "codec" header of each Kafka message
+const (
+	NameJSON     = "json"
+	NameProtobuf = "protobuf"
+	NameAvro     = "avro"
+)
+
+// Codec serializes weather messages for Kafka
+type Codec interface {
+	// Name returns the codec name advertised in message headers
+	Name() string
+	// Marshal encodes a message
+	Marshal(msg models.WeatherMessage) ([]byte, error)
+	// Unmarshal decodes a message encoded by Marshal
+	Unmarshal(data []byte, msg *models.WeatherMessage) error
+}
+
+// codecs holds the supported codecs by name
+var codecs = map[string]Codec{
+	NameJSON:     jsonCodec{},
+	NameProtobuf: protobufCodec{},
+	NameAvro:     avroCodec{},
+}
+
+// New returns the codec with the given name; an empty name means JSON, the format used before codecs were advertised
+func New(name string) (Codec, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		name = NameJSON
+	}
+
+	c, exists := codecs[name]
+	if !exists {
+		return nil, fmt.Errorf("unknown codec '%s' (supported: %s)", name, strings.Join(Names(), ", "))
+	}
+	return c, nil
+}
+
+// Names returns the names of the supported codecs
+func Names() []string {
+	names := make([]string, 0, len(codecs))
+	for name := range codecs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RequiresSchema reports whether messages in the codec carry a registry schema ID
+func RequiresSchema(name string) bool {
+	return name != NameJSON
+}
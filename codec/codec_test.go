@@ -0,0 +1,410 @@
+package codec
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/abhijeet1999/weather/models"
+)
+
+func TestRoundTrip(t *testing.T) {
+	for _, codecName := range Names() {
+		c, err := New(codecName)
+		if err != nil {
+			t.Fatalf("New(%q): %v", codecName, err)
+		}
+
+		for _, tt := range testMessages() {
+			t.Run(codecName+"/"+tt.name, func(t *testing.T) {
+				data, err := c.Marshal(tt.msg)
+				if err != nil {
+					t.Fatalf("Marshal: %v", err)
+				}
+
+				var got models.WeatherMessage
+				if err := c.Unmarshal(data, &got); err != nil {
+					t.Fatalf("Unmarshal: %v", err)
+				}
+				if !reflect.DeepEqual(got, tt.msg) {
+					t.Errorf("decoded message differs\n got: %+v\nwant: %+v", got, tt.msg)
+				}
+			})
+		}
+	}
+}
+
+func TestUnmarshalRejectsTruncatedMessages(t *testing.T) {
+	msg := testMessage(models.MessageTypeCurrent, func(msg *models.WeatherMessage) {
+		msg.Current = testCurrent()
+	})
+
+	for _, codecName := range Names() {
+		t.Run(codecName, func(t *testing.T) {
+			c, err := New(codecName)
+			if err != nil {
+				t.Fatalf("New(%q): %v", codecName, err)
+			}
+			data, err := c.Marshal(msg)
+			if err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+
+			var got models.WeatherMessage
+			if err := c.Unmarshal(data[:len(data)/2], &got); err == nil {
+				t.Error("Unmarshal of a truncated message succeeded")
+			}
+		})
+	}
+}
+
+// TestDecodeRegisteredVersions encodes each message type the way a writer of every registered schema version
+// would, and checks that the current reader decodes it with the fields that version lacks left at their zero values.
+func TestDecodeRegisteredVersions(t *testing.T) {
+	registry, err := LoadRegistry("../schemas/registry.yaml")
+	if err != nil {
+		t.Fatalf("LoadRegistry: %v", err)
+	}
+
+	writers := map[string]func(msg models.WeatherMessage, minor int) ([]byte, error){
+		NameProtobuf: marshalProtobufVersion,
+		NameAvro:     marshalAvroVersion,
+	}
+
+	for _, codecName := range []string{NameProtobuf, NameAvro} {
+		versions := registeredVersions(registry, codecName)
+		if len(versions) == 0 {
+			t.Fatalf("no %s schemas registered", codecName)
+		}
+		if latest := versions[len(versions)-1]; latest != models.MessageSchemaVersion {
+			t.Errorf("latest registered %s schema is %s, want %s", codecName, latest, models.MessageSchemaVersion)
+		}
+
+		reader, err := New(codecName)
+		if err != nil {
+			t.Fatalf("New(%q): %v", codecName, err)
+		}
+
+		for _, version := range versions {
+			_, minor, err := models.ParseSchemaVersion(version)
+			if err != nil {
+				t.Fatalf("ParseSchemaVersion(%q): %v", version, err)
+			}
+
+			for _, tt := range testMessages() {
+				t.Run(codecName+"/"+version+"/"+tt.name, func(t *testing.T) {
+					want := atVersion(tt.msg, version, minor)
+
+					data, err := writers[codecName](want, minor)
+					if err != nil {
+						t.Fatalf("encode: %v", err)
+					}
+
+					var got models.WeatherMessage
+					if err := reader.Unmarshal(data, &got); err != nil {
+						t.Fatalf("Unmarshal: %v", err)
+					}
+
+					assertNewFieldsZero(t, got, minor)
+					if !reflect.DeepEqual(got, want) {
+						t.Errorf("decoded message differs\n got: %+v\nwant: %+v", got, want)
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestRegistryCoversCurrentSchemaVersion(t *testing.T) {
+	registry, err := LoadRegistry("../schemas/registry.yaml")
+	if err != nil {
+		t.Fatalf("LoadRegistry: %v", err)
+	}
+
+	for _, codecName := range Names() {
+		if !RequiresSchema(codecName) {
+			continue
+		}
+		schema, err := registry.Lookup(codecName, models.MessageSchemaVersion)
+		if err != nil {
+			t.Errorf("Lookup(%q): %v", codecName, err)
+			continue
+		}
+		if got, exists := registry.Schema(schema.ID); !exists || got != schema {
+			t.Errorf("Schema(%d) = %+v, %v, want %+v", schema.ID, got, exists, schema)
+		}
+		if schema.Fingerprint == "" {
+			t.Errorf("%s schema %d has no fingerprint", codecName, schema.ID)
+		}
+	}
+}
+
+// registeredVersions returns the schema versions registered for a codec, oldest first
+func registeredVersions(registry *Registry, codecName string) []string {
+	var versions []string
+	for _, schema := range registry.schemas {
+		if schema.Codec == codecName {
+			versions = append(versions, schema.Version)
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		_, a, _ := models.ParseSchemaVersion(versions[i])
+		_, b, _ := models.ParseSchemaVersion(versions[j])
+		return a < b
+	})
+	return versions
+}
+
+// atVersion returns msg as a writer of the given schema version would send it, without the fields added later
+func atVersion(msg models.WeatherMessage, version string, minor int) models.WeatherMessage {
+	msg.SchemaVersion = version
+	if minor < 1 {
+		msg.MessageID = ""
+	}
+	if minor < 2 {
+		msg.Timezone = ""
+		msg.UTCOffset = 0
+	}
+	if minor < 3 && msg.Daily != nil {
+		daily := *msg.Daily
+		daily.FeelsLikeMin, daily.FeelsLikeMax, daily.WindGust = 0, 0, 0
+		daily.Clouds = 0
+		daily.Pop, daily.Rain, daily.Snow = 0, 0, 0
+		msg.Daily = &daily
+	}
+	if minor < 4 {
+		if msg.Current != nil {
+			current := *msg.Current
+			current.Wind.Gust, current.Rain, current.Snow = 0, nil, nil
+			msg.Current = &current
+		}
+		if msg.Forecast != nil {
+			forecast := *msg.Forecast
+			forecast.List = make([]models.ForecastItem, len(msg.Forecast.List))
+			for i, item := range msg.Forecast.List {
+				item.Wind.Gust, item.Rain, item.Snow = 0, nil, nil
+				forecast.List[i] = item
+			}
+			msg.Forecast = &forecast
+		}
+		if msg.Hourly != nil {
+			hourly := *msg.Hourly
+			hourly.Wind.Gust, hourly.Rain, hourly.Snow = 0, nil, nil
+			msg.Hourly = &hourly
+		}
+	}
+	return msg
+}
+
+// assertNewFieldsZero checks that fields added after the writer's schema version decode as zero values
+func assertNewFieldsZero(t *testing.T, msg models.WeatherMessage, minor int) {
+	t.Helper()
+	if minor < 1 && msg.MessageID != "" {
+		t.Errorf("message_id = %q, want empty before 1.1", msg.MessageID)
+	}
+	if minor < 2 && (msg.Timezone != "" || msg.UTCOffset != 0) {
+		t.Errorf("timezone, utc_offset = %q, %d, want zero before 1.2", msg.Timezone, msg.UTCOffset)
+	}
+	if minor < 3 && msg.Daily != nil {
+		daily := *msg.Daily
+		if daily.FeelsLikeMin != 0 || daily.FeelsLikeMax != 0 || daily.WindGust != 0 || daily.Clouds != 0 ||
+			daily.Pop != 0 || daily.Rain != 0 || daily.Snow != 0 {
+			t.Errorf("daily = %+v, want the 1.3 fields zero before 1.3", daily)
+		}
+	}
+	if minor < 4 {
+		if msg.Current != nil && (msg.Current.Wind.Gust != 0 || msg.Current.Rain != nil || msg.Current.Snow != nil) {
+			t.Errorf("current gust, rain, snow = %v, %v, %v, want zero before 1.4", msg.Current.Wind.Gust, msg.Current.Rain, msg.Current.Snow)
+		}
+		var items []models.ForecastItem
+		if msg.Forecast != nil {
+			items = append(items, msg.Forecast.List...)
+		}
+		if msg.Hourly != nil {
+			items = append(items, *msg.Hourly)
+		}
+		for _, item := range items {
+			if item.Wind.Gust != 0 || item.Rain != nil || item.Snow != nil {
+				t.Errorf("forecast item gust, rain, snow = %v, %v, %v, want zero before 1.4", item.Wind.Gust, item.Rain, item.Snow)
+			}
+		}
+	}
+}
+
+// marshalProtobufVersion encodes msg as a Protobuf writer of an older schema version would. Older schemas only lack
+// fields, and zero fields are never written, so the current writer produces the same bytes once they are cleared.
+func marshalProtobufVersion(msg models.WeatherMessage, minor int) ([]byte, error) {
+	return protobufCodec{}.Marshal(msg)
+}
+
+// marshalAvroVersion encodes msg as an Avro writer of an older schema version would, by dropping the trailing
+// fields that version does not have
+func marshalAvroVersion(msg models.WeatherMessage, minor int) ([]byte, error) {
+	data, err := avroCodec{}.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	var trailer avroWriter
+	if minor < 1 {
+		trailer.string(msg.MessageID)
+	}
+	if minor < 2 {
+		trailer.string(msg.Timezone)
+		trailer.int(msg.UTCOffset)
+	}
+	if minor < 3 && trailer.optional(msg.Daily != nil) {
+		writeAvroDailyDetails(&trailer, *msg.Daily)
+	}
+	if minor < 4 {
+		if trailer.optional(msg.Current != nil) {
+			writeAvroWeatherDetails(&trailer, msg.Current.Wind.Gust, msg.Current.Rain, msg.Current.Snow)
+		}
+		if trailer.optional(msg.Forecast != nil) {
+			trailer.arrayBlock(len(msg.Forecast.List))
+			for _, item := range msg.Forecast.List {
+				writeAvroWeatherDetails(&trailer, item.Wind.Gust, item.Rain, item.Snow)
+			}
+			trailer.arrayEnd()
+		}
+		if trailer.optional(msg.Hourly != nil) {
+			writeAvroWeatherDetails(&trailer, msg.Hourly.Wind.Gust, msg.Hourly.Rain, msg.Hourly.Snow)
+		}
+	}
+	return data[:len(data)-len(trailer.b)], nil
+}
+
+// namedMessage is a test message and the subtest name it runs under
+type namedMessage struct {
+	name string
+	msg  models.WeatherMessage
+}
+
+// testMessages returns a message of each type with every field the binary codecs carry set
+func testMessages() []namedMessage {
+	return []namedMessage{
+		{name: models.MessageTypeCurrent, msg: testMessage(models.MessageTypeCurrent, func(msg *models.WeatherMessage) {
+			msg.Current = testCurrent()
+		})},
+		{name: models.MessageTypeForecast, msg: testMessage(models.MessageTypeForecast, func(msg *models.WeatherMessage) {
+			msg.Forecast = testForecast()
+		})},
+		{name: models.MessageTypeHourly, msg: testMessage(models.MessageTypeHourly, func(msg *models.WeatherMessage) {
+			msg.Hourly = &testForecast().List[0]
+		})},
+		{name: models.MessageTypeDaily, msg: testMessage(models.MessageTypeDaily, func(msg *models.WeatherMessage) {
+			msg.Daily = testDaily()
+		})},
+	}
+}
+
+// testMessage builds a message with every envelope field set
+func testMessage(messageType string, body func(msg *models.WeatherMessage)) models.WeatherMessage {
+	msg := models.WeatherMessage{
+		SchemaVersion: models.MessageSchemaVersion,
+		Timestamp:     time.UnixMilli(1717243200123).UTC(),
+		ZipCode:       "10115",
+		City:          "Berlin",
+		Country:       "DE",
+		Units:         "metric",
+		MessageType:   messageType,
+		MessageID:     "3f1c9a7e5b2d4c60",
+		Timezone:      "UTC+02:00",
+		UTCOffset:     7200,
+	}
+	body(&msg)
+	return msg
+}
+
+// testCurrent returns current weather with every field the binary codecs carry set
+func testCurrent() *models.OpenWeatherResponse {
+	var current models.OpenWeatherResponse
+	current.Coord.Lat = 52.5244
+	current.Coord.Lon = 13.4105
+	current.Weather = []models.OpenWeatherCondition{{Id: 803, Main: "Clouds", Description: "broken clouds", Icon: "04d"}}
+	current.Main.Temp = 18.5
+	current.Main.FeelsLike = 17.25
+	current.Main.TempMin = 16
+	current.Main.TempMax = 20.5
+	current.Main.Pressure = 1012
+	current.Main.Humidity = 64
+	current.Visibility = 10000
+	current.Wind.Speed = 4.5
+	current.Wind.Deg = 250
+	current.Wind.Gust = 8.25
+	current.Rain = &models.Precipitation{OneHour: 0.5}
+	current.Snow = &models.Precipitation{OneHour: 0.25, ThreeHours: 0.75}
+	current.Clouds.All = 75
+	current.Dt = 1717243200
+	current.Sys.Country = "DE"
+	current.Sys.Sunrise = 1717211000
+	current.Sys.Sunset = 1717270000
+	current.Timezone = 7200
+	current.Id = 2950159
+	current.Name = "Berlin"
+	return &current
+}
+
+// testForecast returns a forecast with every field the binary codecs carry set
+func testForecast() *models.OpenWeatherForecastResponse {
+	var forecast models.OpenWeatherForecastResponse
+	forecast.City.Id = 2950159
+	forecast.City.Name = "Berlin"
+	forecast.City.Coord.Lat = 52.5244
+	forecast.City.Coord.Lon = 13.4105
+	forecast.City.Country = "DE"
+	forecast.City.Timezone = 7200
+	forecast.City.Sunrise = 1717211000
+	forecast.City.Sunset = 1717270000
+
+	for i, pod := range []string{"d", "n"} {
+		var item models.ForecastItem
+		item.Dt = 1717243200 + int64(i)*10800
+		item.Main.Temp = 18.5 - float32(i)
+		item.Main.FeelsLike = 17.25
+		item.Main.TempMin = 16
+		item.Main.TempMax = 20.5
+		item.Main.Pressure = 1012
+		item.Main.Humidity = 64 + i
+		item.Weather = []models.OpenWeatherCondition{{Id: 500, Main: "Rain", Description: "light rain", Icon: "10" + pod}}
+		item.Clouds.All = 90
+		item.Wind.Speed = 3.75
+		item.Wind.Deg = 240
+		item.Wind.Gust = 6.5 + float32(i)
+		if pod == "d" {
+			item.Rain = &models.Precipitation{ThreeHours: 1.25}
+		} else {
+			item.Snow = &models.Precipitation{ThreeHours: 0.5}
+		}
+		item.Visibility = 9000
+		item.Pop = 0.5
+		item.Sys.Pod = pod
+		item.DtTxt = time.Unix(item.Dt, 0).UTC().Format("2006-01-02 15:04:05")
+		forecast.List = append(forecast.List, item)
+	}
+	forecast.Cnt = len(forecast.List)
+	return &forecast
+}
+
+// testDaily returns a daily summary with every field set, including those added in 1.3
+func testDaily() *models.DailyWeatherData {
+	return &models.DailyWeatherData{
+		Day:          2,
+		Date:         "2024-06-02",
+		TempMin:      12.5,
+		TempMax:      21,
+		TempAvg:      16.75,
+		Humidity:     70,
+		WindSpeed:    4.25,
+		Description:  "light rain",
+		Icon:         "10d",
+		FeelsLikeMin: 11.5,
+		FeelsLikeMax: 20.25,
+		WindGust:     9.5,
+		Clouds:       40,
+		Pop:          0.75,
+		Rain:         3.5,
+		Snow:         0.25,
+	}
+}
@@ -0,0 +1,25 @@
+package codec
+
+import (
+	"encoding/json"
+
+	"github.com/abhijeet1999/weather/models"
+)
+
+// jsonCodec encodes messages as JSON, carrying the full provider responses
+type jsonCodec struct{}
+
+// Name returns the codec name
+func (jsonCodec) Name() string {
+	return NameJSON
+}
+
+// Marshal encodes a message as JSON
+func (jsonCodec) Marshal(msg models.WeatherMessage) ([]byte, error) {
+	return json.Marshal(msg)
+}
+
+// Unmarshal decodes a JSON message
+func (jsonCodec) Unmarshal(data []byte, msg *models.WeatherMessage) error {
+	return json.Unmarshal(data, msg)
+}
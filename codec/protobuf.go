@@ -0,0 +1,551 @@
+package codec
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/abhijeet1999/weather/models"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// protobufCodec encodes messages with the weather.v1.WeatherMessage schema in schemas/weather_message_v1_4.proto.
+// Only the fields used downstream are carried; provider bookkeeping such as cod and base is dropped.
+type protobufCodec struct{}
+
+// Name returns the codec name
+func (protobufCodec) Name() string {
+	return NameProtobuf
+}
+
+// Marshal encodes a message as Protobuf
+func (protobufCodec) Marshal(msg models.WeatherMessage) ([]byte, error) {
+	var w protoWriter
+	w.string(1, msg.SchemaVersion)
+	w.int(2, msg.Timestamp.UnixMilli())
+	w.string(3, msg.ZipCode)
+	w.string(4, msg.City)
+	w.string(5, msg.Country)
+	w.string(6, msg.Units)
+	w.string(7, msg.MessageType)
+	if msg.Current != nil {
+		w.message(8, marshalProtoCurrent(*msg.Current))
+	}
+	if msg.Forecast != nil {
+		w.message(9, marshalProtoForecast(*msg.Forecast))
+	}
+	if msg.Hourly != nil {
+		w.message(10, marshalProtoForecastItem(*msg.Hourly))
+	}
+	if msg.Daily != nil {
+		w.message(11, marshalProtoDaily(*msg.Daily))
+	}
+	w.string(12, msg.MessageID)
+	w.string(13, msg.Timezone)
+	w.int(14, int64(msg.UTCOffset))
+	return w.b, nil
+}
+
+// Unmarshal decodes a Protobuf message; unknown fields from newer schemas are skipped
+func (protobufCodec) Unmarshal(data []byte, msg *models.WeatherMessage) error {
+	err := readProto(data, func(f protoField) error {
+		switch f.num {
+		case 1:
+			msg.SchemaVersion = f.string()
+		case 2:
+			msg.Timestamp = time.UnixMilli(f.int()).UTC()
+		case 3:
+			msg.ZipCode = f.string()
+		case 4:
+			msg.City = f.string()
+		case 5:
+			msg.Country = f.string()
+		case 6:
+			msg.Units = f.string()
+		case 7:
+			msg.MessageType = f.string()
+		case 8:
+			var current models.OpenWeatherResponse
+			if err := unmarshalProtoCurrent(f.bytes, &current); err != nil {
+				return err
+			}
+			msg.Current = &current
+		case 9:
+			var forecast models.OpenWeatherForecastResponse
+			if err := unmarshalProtoForecast(f.bytes, &forecast); err != nil {
+				return err
+			}
+			msg.Forecast = &forecast
+		case 10:
+			var hourly models.ForecastItem
+			if err := unmarshalProtoForecastItem(f.bytes, &hourly); err != nil {
+				return err
+			}
+			msg.Hourly = &hourly
+		case 11:
+			var daily models.DailyWeatherData
+			if err := unmarshalProtoDaily(f.bytes, &daily); err != nil {
+				return err
+			}
+			msg.Daily = &daily
+		case 12:
+			msg.MessageID = f.string()
+		case 13:
+			msg.Timezone = f.string()
+		case 14:
+			msg.UTCOffset = int(int32(f.int()))
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("invalid protobuf message: %v", err)
+	}
+	return nil
+}
+
+// marshalProtoCurrent encodes a weather.v1.CurrentWeather
+func marshalProtoCurrent(current models.OpenWeatherResponse) []byte {
+	var w protoWriter
+	w.double(1, current.Coord.Lat)
+	w.double(2, current.Coord.Lon)
+	for _, condition := range current.Weather {
+		w.message(3, marshalProtoCondition(condition))
+	}
+	w.float(4, current.Main.Temp)
+	w.float(5, current.Main.FeelsLike)
+	w.float(6, current.Main.TempMin)
+	w.float(7, current.Main.TempMax)
+	w.int(8, int64(current.Main.Pressure))
+	w.int(9, int64(current.Main.Humidity))
+	w.int(10, int64(current.Visibility))
+	w.float(11, current.Wind.Speed)
+	w.int(12, int64(current.Wind.Deg))
+	w.int(13, int64(current.Clouds.All))
+	w.int(14, current.Dt)
+	w.string(15, current.Sys.Country)
+	w.int(16, current.Sys.Sunrise)
+	w.int(17, current.Sys.Sunset)
+	w.int(18, int64(current.Timezone))
+	w.int(19, int64(current.Id))
+	w.string(20, current.Name)
+	w.float(21, current.Wind.Gust)
+	if current.Rain != nil {
+		w.message(22, marshalProtoPrecipitation(*current.Rain))
+	}
+	if current.Snow != nil {
+		w.message(23, marshalProtoPrecipitation(*current.Snow))
+	}
+	return w.b
+}
+
+// unmarshalProtoCurrent decodes a weather.v1.CurrentWeather
+func unmarshalProtoCurrent(data []byte, current *models.OpenWeatherResponse) error {
+	return readProto(data, func(f protoField) error {
+		switch f.num {
+		case 1:
+			current.Coord.Lat = f.double()
+		case 2:
+			current.Coord.Lon = f.double()
+		case 3:
+			var condition models.OpenWeatherCondition
+			if err := unmarshalProtoCondition(f.bytes, &condition); err != nil {
+				return err
+			}
+			current.Weather = append(current.Weather, condition)
+		case 4:
+			current.Main.Temp = f.float()
+		case 5:
+			current.Main.FeelsLike = f.float()
+		case 6:
+			current.Main.TempMin = f.float()
+		case 7:
+			current.Main.TempMax = f.float()
+		case 8:
+			current.Main.Pressure = int(f.int())
+		case 9:
+			current.Main.Humidity = int(f.int())
+		case 10:
+			current.Visibility = int(f.int())
+		case 11:
+			current.Wind.Speed = f.float()
+		case 12:
+			current.Wind.Deg = int(f.int())
+		case 13:
+			current.Clouds.All = int(f.int())
+		case 14:
+			current.Dt = f.int()
+		case 15:
+			current.Sys.Country = f.string()
+		case 16:
+			current.Sys.Sunrise = f.int()
+		case 17:
+			current.Sys.Sunset = f.int()
+		case 18:
+			current.Timezone = int(f.int())
+		case 19:
+			current.Id = int(f.int())
+		case 20:
+			current.Name = f.string()
+		case 21:
+			current.Wind.Gust = f.float()
+		case 22:
+			return unmarshalProtoPrecipitation(f.bytes, &current.Rain)
+		case 23:
+			return unmarshalProtoPrecipitation(f.bytes, &current.Snow)
+		}
+		return nil
+	})
+}
+
+// marshalProtoForecast encodes a weather.v1.Forecast
+func marshalProtoForecast(forecast models.OpenWeatherForecastResponse) []byte {
+	var w protoWriter
+	w.int(1, int64(forecast.City.Id))
+	w.string(2, forecast.City.Name)
+	w.double(3, forecast.City.Coord.Lat)
+	w.double(4, forecast.City.Coord.Lon)
+	w.string(5, forecast.City.Country)
+	w.int(6, int64(forecast.City.Timezone))
+	w.int(7, forecast.City.Sunrise)
+	w.int(8, forecast.City.Sunset)
+	for _, item := range forecast.List {
+		w.message(9, marshalProtoForecastItem(item))
+	}
+	return w.b
+}
+
+// unmarshalProtoForecast decodes a weather.v1.Forecast
+func unmarshalProtoForecast(data []byte, forecast *models.OpenWeatherForecastResponse) error {
+	err := readProto(data, func(f protoField) error {
+		switch f.num {
+		case 1:
+			forecast.City.Id = int(f.int())
+		case 2:
+			forecast.City.Name = f.string()
+		case 3:
+			forecast.City.Coord.Lat = f.double()
+		case 4:
+			forecast.City.Coord.Lon = f.double()
+		case 5:
+			forecast.City.Country = f.string()
+		case 6:
+			forecast.City.Timezone = int(f.int())
+		case 7:
+			forecast.City.Sunrise = f.int()
+		case 8:
+			forecast.City.Sunset = f.int()
+		case 9:
+			var item models.ForecastItem
+			if err := unmarshalProtoForecastItem(f.bytes, &item); err != nil {
+				return err
+			}
+			forecast.List = append(forecast.List, item)
+		}
+		return nil
+	})
+	forecast.Cnt = len(forecast.List)
+	return err
+}
+
+// marshalProtoForecastItem encodes a weather.v1.ForecastItem
+func marshalProtoForecastItem(item models.ForecastItem) []byte {
+	var w protoWriter
+	w.int(1, item.Dt)
+	w.float(2, item.Main.Temp)
+	w.float(3, item.Main.FeelsLike)
+	w.float(4, item.Main.TempMin)
+	w.float(5, item.Main.TempMax)
+	w.int(6, int64(item.Main.Pressure))
+	w.int(7, int64(item.Main.Humidity))
+	for _, condition := range item.Weather {
+		w.message(8, marshalProtoCondition(condition))
+	}
+	w.int(9, int64(item.Clouds.All))
+	w.float(10, item.Wind.Speed)
+	w.int(11, int64(item.Wind.Deg))
+	w.int(12, int64(item.Visibility))
+	w.float(13, item.Pop)
+	w.string(14, item.Sys.Pod)
+	w.string(15, item.DtTxt)
+	w.float(16, item.Wind.Gust)
+	if item.Rain != nil {
+		w.message(17, marshalProtoPrecipitation(*item.Rain))
+	}
+	if item.Snow != nil {
+		w.message(18, marshalProtoPrecipitation(*item.Snow))
+	}
+	return w.b
+}
+
+// unmarshalProtoForecastItem decodes a weather.v1.ForecastItem
+func unmarshalProtoForecastItem(data []byte, item *models.ForecastItem) error {
+	return readProto(data, func(f protoField) error {
+		switch f.num {
+		case 1:
+			item.Dt = f.int()
+		case 2:
+			item.Main.Temp = f.float()
+		case 3:
+			item.Main.FeelsLike = f.float()
+		case 4:
+			item.Main.TempMin = f.float()
+		case 5:
+			item.Main.TempMax = f.float()
+		case 6:
+			item.Main.Pressure = int(f.int())
+		case 7:
+			item.Main.Humidity = int(f.int())
+		case 8:
+			var condition models.OpenWeatherCondition
+			if err := unmarshalProtoCondition(f.bytes, &condition); err != nil {
+				return err
+			}
+			item.Weather = append(item.Weather, condition)
+		case 9:
+			item.Clouds.All = int(f.int())
+		case 10:
+			item.Wind.Speed = f.float()
+		case 11:
+			item.Wind.Deg = int(f.int())
+		case 12:
+			item.Visibility = int(f.int())
+		case 13:
+			item.Pop = f.float()
+		case 14:
+			item.Sys.Pod = f.string()
+		case 15:
+			item.DtTxt = f.string()
+		case 16:
+			item.Wind.Gust = f.float()
+		case 17:
+			return unmarshalProtoPrecipitation(f.bytes, &item.Rain)
+		case 18:
+			return unmarshalProtoPrecipitation(f.bytes, &item.Snow)
+		}
+		return nil
+	})
+}
+
+// marshalProtoCondition encodes a weather.v1.Condition
+func marshalProtoCondition(condition models.OpenWeatherCondition) []byte {
+	var w protoWriter
+	w.int(1, int64(condition.Id))
+	w.string(2, condition.Main)
+	w.string(3, condition.Description)
+	w.string(4, condition.Icon)
+	return w.b
+}
+
+// unmarshalProtoCondition decodes a weather.v1.Condition
+func unmarshalProtoCondition(data []byte, condition *models.OpenWeatherCondition) error {
+	return readProto(data, func(f protoField) error {
+		switch f.num {
+		case 1:
+			condition.Id = int(f.int())
+		case 2:
+			condition.Main = f.string()
+		case 3:
+			condition.Description = f.string()
+		case 4:
+			condition.Icon = f.string()
+		}
+		return nil
+	})
+}
+
+// marshalProtoPrecipitation encodes a weather.v1.Precipitation
+func marshalProtoPrecipitation(precipitation models.Precipitation) []byte {
+	var w protoWriter
+	w.float(1, precipitation.OneHour)
+	w.float(2, precipitation.ThreeHours)
+	return w.b
+}
+
+// unmarshalProtoPrecipitation decodes a weather.v1.Precipitation into a newly allocated value
+func unmarshalProtoPrecipitation(data []byte, precipitation **models.Precipitation) error {
+	var decoded models.Precipitation
+	err := readProto(data, func(f protoField) error {
+		switch f.num {
+		case 1:
+			decoded.OneHour = f.float()
+		case 2:
+			decoded.ThreeHours = f.float()
+		}
+		return nil
+	})
+	*precipitation = &decoded
+	return err
+}
+
+// marshalProtoDaily encodes a weather.v1.DailyWeather
+func marshalProtoDaily(daily models.DailyWeatherData) []byte {
+	var w protoWriter
+	w.int(1, int64(daily.Day))
+	w.string(2, daily.Date)
+	w.float(3, daily.TempMin)
+	w.float(4, daily.TempMax)
+	w.float(5, daily.TempAvg)
+	w.int(6, int64(daily.Humidity))
+	w.float(7, daily.WindSpeed)
+	w.string(8, daily.Description)
+	w.string(9, daily.Icon)
+	w.float(10, daily.FeelsLikeMin)
+	w.float(11, daily.FeelsLikeMax)
+	w.float(12, daily.WindGust)
+	w.int(13, int64(daily.Clouds))
+	w.float(14, daily.Pop)
+	w.float(15, daily.Rain)
+	w.float(16, daily.Snow)
+	return w.b
+}
+
+// unmarshalProtoDaily decodes a weather.v1.DailyWeather
+func unmarshalProtoDaily(data []byte, daily *models.DailyWeatherData) error {
+	return readProto(data, func(f protoField) error {
+		switch f.num {
+		case 1:
+			daily.Day = int(f.int())
+		case 2:
+			daily.Date = f.string()
+		case 3:
+			daily.TempMin = f.float()
+		case 4:
+			daily.TempMax = f.float()
+		case 5:
+			daily.TempAvg = f.float()
+		case 6:
+			daily.Humidity = int(f.int())
+		case 7:
+			daily.WindSpeed = f.float()
+		case 8:
+			daily.Description = f.string()
+		case 9:
+			daily.Icon = f.string()
+		case 10:
+			daily.FeelsLikeMin = f.float()
+		case 11:
+			daily.FeelsLikeMax = f.float()
+		case 12:
+			daily.WindGust = f.float()
+		case 13:
+			daily.Clouds = int(f.int())
+		case 14:
+			daily.Pop = f.float()
+		case 15:
+			daily.Rain = f.float()
+		case 16:
+			daily.Snow = f.float()
+		}
+		return nil
+	})
+}
+
+// protoWriter appends Protobuf fields, omitting zero scalars as proto3 does
+type protoWriter struct {
+	b []byte
+}
+
+// string appends a string field
+func (w *protoWriter) string(num protowire.Number, v string) {
+	if v == "" {
+		return
+	}
+	w.b = protowire.AppendTag(w.b, num, protowire.BytesType)
+	w.b = protowire.AppendString(w.b, v)
+}
+
+// int appends an int32 or int64 field
+func (w *protoWriter) int(num protowire.Number, v int64) {
+	if v == 0 {
+		return
+	}
+	w.b = protowire.AppendTag(w.b, num, protowire.VarintType)
+	w.b = protowire.AppendVarint(w.b, uint64(v))
+}
+
+// float appends a float field
+func (w *protoWriter) float(num protowire.Number, v float32) {
+	if v == 0 {
+		return
+	}
+	w.b = protowire.AppendTag(w.b, num, protowire.Fixed32Type)
+	w.b = protowire.AppendFixed32(w.b, math.Float32bits(v))
+}
+
+// double appends a double field
+func (w *protoWriter) double(num protowire.Number, v float64) {
+	if v == 0 {
+		return
+	}
+	w.b = protowire.AppendTag(w.b, num, protowire.Fixed64Type)
+	w.b = protowire.AppendFixed64(w.b, math.Float64bits(v))
+}
+
+// message appends an embedded message field; it is written even when empty so presence is preserved
+func (w *protoWriter) message(num protowire.Number, v []byte) {
+	w.b = protowire.AppendTag(w.b, num, protowire.BytesType)
+	w.b = protowire.AppendBytes(w.b, v)
+}
+
+// protoField is a single decoded Protobuf field
+type protoField struct {
+	num     protowire.Number
+	varint  uint64
+	fixed32 uint32
+	fixed64 uint64
+	bytes   []byte
+}
+
+// int returns the value of an int32 or int64 field
+func (f protoField) int() int64 {
+	return int64(f.varint)
+}
+
+// float returns the value of a float field
+func (f protoField) float() float32 {
+	return math.Float32frombits(f.fixed32)
+}
+
+// double returns the value of a double field
+func (f protoField) double() float64 {
+	return math.Float64frombits(f.fixed64)
+}
+
+// string returns the value of a string field
+func (f protoField) string() string {
+	return string(f.bytes)
+}
+
+// readProto calls fn for each field of an encoded message
+func readProto(data []byte, fn func(f protoField) error) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		f := protoField{num: num}
+		switch typ {
+		case protowire.VarintType:
+			f.varint, n = protowire.ConsumeVarint(data)
+		case protowire.Fixed32Type:
+			f.fixed32, n = protowire.ConsumeFixed32(data)
+		case protowire.Fixed64Type:
+			f.fixed64, n = protowire.ConsumeFixed64(data)
+		case protowire.BytesType:
+			f.bytes, n = protowire.ConsumeBytes(data)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, data)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		if err := fn(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package codec
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/abhijeet1999/weather/models"
+	"gopkg.in/yaml.v3"
+)
+
+// Schema is a registered message schema for a binary codec
+type Schema struct {
+	ID          int    `yaml:"id"`
+	Codec       string `yaml:"codec"`
+	Version     string `yaml:"version"` // Message schema version ("major.minor") the schema describes
+	File        string `yaml:"file"`    // Schema definition, relative to the registry file
+	Fingerprint string `yaml:"-"`       // SHA-256 of the schema definition
+}
+
+// Registry is a file-based stand-in for a schema registry. Producers stamp each binary message with the ID
+// of the schema it was written with, and consumers look the ID up to decide whether they can read it.
+type Registry struct {
+	path    string
+	schemas map[int]Schema
+}
+
+// LoadRegistry loads and validates a registry file
+func LoadRegistry(path string) (*Registry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema registry: %v", err)
+	}
+
+	var doc struct {
+		Schemas []Schema `yaml:"schemas"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid schema registry %s: %v", path, err)
+	}
+
+	registry := &Registry{
+		path:    path,
+		schemas: make(map[int]Schema, len(doc.Schemas)),
+	}
+
+	for i, schema := range doc.Schemas {
+		if err := registry.add(schema); err != nil {
+			return nil, fmt.Errorf("invalid schema registry %s: schemas[%d]: %v", path, i, err)
+		}
+	}
+
+	return registry, nil
+}
+
+// add validates a schema, fingerprints its definition and registers it
+func (r *Registry) add(schema Schema) error {
+	if schema.ID <= 0 {
+		return fmt.Errorf("id must be a positive integer")
+	}
+	if _, exists := r.schemas[schema.ID]; exists {
+		return fmt.Errorf("duplicate id %d", schema.ID)
+	}
+	if _, err := New(schema.Codec); err != nil || !RequiresSchema(schema.Codec) {
+		return fmt.Errorf("codec must be %s or %s", NameProtobuf, NameAvro)
+	}
+	if _, _, err := models.ParseSchemaVersion(schema.Version); err != nil {
+		return err
+	}
+
+	for _, existing := range r.schemas {
+		if existing.Codec == schema.Codec && existing.Version == schema.Version {
+			return fmt.Errorf("%s version %s is already registered as id %d", schema.Codec, schema.Version, existing.ID)
+		}
+	}
+
+	definition, err := os.ReadFile(filepath.Join(filepath.Dir(r.path), schema.File))
+	if err != nil {
+		return fmt.Errorf("failed to read schema file: %v", err)
+	}
+	sum := sha256.Sum256(definition)
+	schema.Fingerprint = hex.EncodeToString(sum[:])
+
+	r.schemas[schema.ID] = schema
+	return nil
+}
+
+// Lookup returns the schema registered for a codec and message schema version
+func (r *Registry) Lookup(codec, version string) (Schema, error) {
+	if r != nil {
+		for _, schema := range r.schemas {
+			if schema.Codec == codec && schema.Version == version {
+				return schema, nil
+			}
+		}
+	}
+	return Schema{}, fmt.Errorf("no %s schema registered for message schema version %s", codec, version)
+}
+
+// Schema returns the schema registered under an ID
+func (r *Registry) Schema(id int) (Schema, bool) {
+	if r == nil {
+		return Schema{}, false
+	}
+	schema, exists := r.schemas[id]
+	return schema, exists
+}
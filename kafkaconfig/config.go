@@ -0,0 +1,241 @@
+package kafkaconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+)
+
+// Supported SASL mechanisms
+const (
+	SASLPlain       = "PLAIN"
+	SASLScramSHA256 = "SCRAM-SHA-256"
+	SASLScramSHA512 = "SCRAM-SHA-512"
+)
+
+// dialTimeout bounds connecting and authenticating to a broker
+const dialTimeout = 10 * time.Second
+
+// ErrNoBrokers is returned when no broker address is configured
+var ErrNoBrokers = errors.New("no Kafka brokers configured")
+
+// Config holds the Kafka connection settings shared by the producer, the consumer and the replay tool
+type Config struct {
+	Brokers  []string
+	ClientID string
+	TLS      TLSConfig
+	SASL     SASLConfig
+}
+
+// TLSConfig holds TLS settings; certificate files are PEM encoded
+type TLSConfig struct {
+	Enabled            bool
+	CAFile             string // CA bundle used to verify brokers; empty means the system roots
+	CertFile           string // Client certificate for mutual TLS
+	KeyFile            string
+	InsecureSkipVerify bool
+}
+
+// SASLConfig holds SASL authentication settings
+type SASLConfig struct {
+	Mechanism string // PLAIN, SCRAM-SHA-256 or SCRAM-SHA-512; empty disables SASL
+	Username  string
+	Password  string
+}
+
+// FromEnv reads connection settings from environment variables. TLS is enabled by KAFKA_TLS_ENABLED or by
+// setting any of the certificate files.
+func FromEnv(defaultBrokers, defaultClientID string) Config {
+	config := Config{
+		Brokers:  ParseBrokers(getEnvOrDefault("KAFKA_SERVERS", defaultBrokers)),
+		ClientID: getEnvOrDefault("KAFKA_CLIENT_ID", defaultClientID),
+		TLS: TLSConfig{
+			CAFile:             os.Getenv("KAFKA_TLS_CA_FILE"),
+			CertFile:           os.Getenv("KAFKA_TLS_CERT_FILE"),
+			KeyFile:            os.Getenv("KAFKA_TLS_KEY_FILE"),
+			InsecureSkipVerify: getBoolEnv("KAFKA_TLS_INSECURE_SKIP_VERIFY"),
+		},
+		SASL: SASLConfig{
+			Mechanism: strings.ToUpper(strings.TrimSpace(os.Getenv("KAFKA_SASL_MECHANISM"))),
+			Username:  os.Getenv("KAFKA_SASL_USERNAME"),
+			Password:  os.Getenv("KAFKA_SASL_PASSWORD"),
+		},
+	}
+	config.TLS.Enabled = getBoolEnv("KAFKA_TLS_ENABLED") ||
+		config.TLS.CAFile != "" || config.TLS.CertFile != "" || config.TLS.KeyFile != ""
+
+	return config
+}
+
+// ParseBrokers splits a comma-separated broker list, trimming spaces and dropping empty entries
+func ParseBrokers(list string) []string {
+	var brokers []string
+	for _, broker := range strings.Split(list, ",") {
+		if broker = strings.TrimSpace(broker); broker != "" {
+			brokers = append(brokers, broker)
+		}
+	}
+	return brokers
+}
+
+// Connection is a validated Config with certificates loaded and the SASL mechanism built,
+// ready to create readers and writers
+type Connection struct {
+	brokers   []string
+	clientID  string
+	tls       *tls.Config    // Nil for plaintext
+	mechanism sasl.Mechanism // Nil without SASL
+}
+
+// NewConnection validates a Config and loads its certificates
+func NewConnection(config Config) (*Connection, error) {
+	if len(config.Brokers) == 0 {
+		return nil, ErrNoBrokers
+	}
+
+	tlsConfig, err := config.TLS.build()
+	if err != nil {
+		return nil, err
+	}
+
+	mechanism, err := config.SASL.build()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Connection{
+		brokers:   config.Brokers,
+		clientID:  config.ClientID,
+		tls:       tlsConfig,
+		mechanism: mechanism,
+	}, nil
+}
+
+// Brokers returns the broker addresses
+func (c *Connection) Brokers() []string {
+	return c.brokers
+}
+
+// Addr returns the broker addresses for a kafka.Writer
+func (c *Connection) Addr() net.Addr {
+	return kafka.TCP(c.brokers...)
+}
+
+// Dialer returns a dialer for kafka.Reader
+func (c *Connection) Dialer() *kafka.Dialer {
+	return &kafka.Dialer{
+		Timeout:       dialTimeout,
+		DualStack:     true,
+		ClientID:      c.clientID,
+		TLS:           c.tls,
+		SASLMechanism: c.mechanism,
+	}
+}
+
+// Transport returns a transport for kafka.Writer
+func (c *Connection) Transport() *kafka.Transport {
+	return &kafka.Transport{
+		DialTimeout: dialTimeout,
+		ClientID:    c.clientID,
+		TLS:         c.tls,
+		SASL:        c.mechanism,
+	}
+}
+
+// String describes the connection for logs, without credentials
+func (c *Connection) String() string {
+	security := "plaintext"
+	switch {
+	case c.mechanism != nil && c.tls != nil:
+		security = "SASL " + c.mechanism.Name() + " over TLS"
+	case c.mechanism != nil:
+		security = "SASL " + c.mechanism.Name()
+	case c.tls != nil:
+		security = "TLS"
+	}
+	return fmt.Sprintf("%s (%s, client ID %q)", strings.Join(c.brokers, ","), security, c.clientID)
+}
+
+// build returns the tls.Config described by the settings, or nil when TLS is disabled
+func (t TLSConfig) build() (*tls.Config, error) {
+	if !t.Enabled {
+		return nil, nil
+	}
+
+	config := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: t.InsecureSkipVerify,
+	}
+
+	if t.CAFile != "" {
+		pem, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Kafka CA file: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in Kafka CA file %s", t.CAFile)
+		}
+		config.RootCAs = pool
+	}
+
+	if t.CertFile != "" || t.KeyFile != "" {
+		if t.CertFile == "" || t.KeyFile == "" {
+			return nil, fmt.Errorf("client certificate and key files must be set together for Kafka mutual TLS")
+		}
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load Kafka client certificate: %v", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
+
+// build returns the SASL mechanism described by the settings, or nil when SASL is disabled
+func (s SASLConfig) build() (sasl.Mechanism, error) {
+	if s.Mechanism == "" {
+		return nil, nil
+	}
+	if s.Username == "" {
+		return nil, fmt.Errorf("SASL mechanism %s requires a username", s.Mechanism)
+	}
+
+	switch s.Mechanism {
+	case SASLPlain:
+		return plain.Mechanism{Username: s.Username, Password: s.Password}, nil
+	case SASLScramSHA256:
+		return scram.Mechanism(scram.SHA256, s.Username, s.Password)
+	case SASLScramSHA512:
+		return scram.Mechanism(scram.SHA512, s.Username, s.Password)
+	default:
+		return nil, fmt.Errorf("unsupported SASL mechanism %q (supported: %s, %s, %s)",
+			s.Mechanism, SASLPlain, SASLScramSHA256, SASLScramSHA512)
+	}
+}
+
+// getEnvOrDefault returns environment variable value or default
+func getEnvOrDefault(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// getBoolEnv returns environment variable parsed as a boolean; unset or invalid values are false
+func getBoolEnv(key string) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	return err == nil && value
+}
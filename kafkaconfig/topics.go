@@ -0,0 +1,101 @@
+package kafkaconfig
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/abhijeet1999/weather/models"
+)
+
+// MessageTypes lists every message type in the order they are documented
+var MessageTypes = []string{
+	models.MessageTypeCurrent,
+	models.MessageTypeForecast,
+	models.MessageTypeHourly,
+	models.MessageTypeDaily,
+}
+
+// Topics routes each message type to a topic, so consumers that only want some message types
+// do not have to read the others
+type Topics struct {
+	Default string            // Topic for message types without a route of their own
+	Routes  map[string]string // Message type to topic
+}
+
+// TopicsFromEnv reads the default topic from KAFKA_TOPIC and per-type overrides from KAFKA_TOPIC_<TYPE>,
+// e.g. KAFKA_TOPIC_FORECAST
+func TopicsFromEnv(defaultTopic string) Topics {
+	topics := Topics{
+		Default: getEnvOrDefault("KAFKA_TOPIC", defaultTopic),
+		Routes:  make(map[string]string),
+	}
+	for _, messageType := range MessageTypes {
+		if topic := strings.TrimSpace(os.Getenv("KAFKA_TOPIC_" + strings.ToUpper(messageType))); topic != "" {
+			topics.Routes[messageType] = topic
+		}
+	}
+	return topics
+}
+
+// For returns the topic a message type is routed to
+func (t Topics) For(messageType string) string {
+	if topic, exists := t.Routes[messageType]; exists && topic != "" {
+		return topic
+	}
+	return t.Default
+}
+
+// Subscriptions returns the topics carrying the given message types, each with the message types routed to it
+func (t Topics) Subscriptions(messageTypes []string) map[string][]string {
+	subscriptions := make(map[string][]string)
+	for _, messageType := range messageTypes {
+		topic := t.For(messageType)
+		subscriptions[topic] = append(subscriptions[topic], messageType)
+	}
+	return subscriptions
+}
+
+// String describes the routing for logs
+func (t Topics) String() string {
+	routes := make([]string, 0, len(MessageTypes))
+	for _, messageType := range MessageTypes {
+		routes = append(routes, fmt.Sprintf("%s→%s", messageType, t.For(messageType)))
+	}
+	return strings.Join(routes, ", ")
+}
+
+// ParseMessageTypes splits a comma-separated list of message types; an empty list means every type
+func ParseMessageTypes(list string) ([]string, error) {
+	if strings.TrimSpace(list) == "" {
+		return MessageTypes, nil
+	}
+
+	var messageTypes []string
+	seen := make(map[string]bool)
+	for _, messageType := range strings.Split(list, ",") {
+		messageType = strings.ToLower(strings.TrimSpace(messageType))
+		if messageType == "" || seen[messageType] {
+			continue
+		}
+		if !IsMessageType(messageType) {
+			return nil, fmt.Errorf("unknown message type %q (supported: %s)", messageType, strings.Join(MessageTypes, ", "))
+		}
+		seen[messageType] = true
+		messageTypes = append(messageTypes, messageType)
+	}
+	if len(messageTypes) == 0 {
+		return MessageTypes, nil
+	}
+	return messageTypes, nil
+}
+
+// IsMessageType reports whether messageType is a known message type
+func IsMessageType(messageType string) bool {
+	for _, known := range MessageTypes {
+		if known == messageType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kafka message schema version. The major version changes when a field is removed or its meaning changes;
+// the minor version changes when optional fields are added, which older readers can safely ignore.
+const (
+	MessageSchemaMajor   = 1
+	MessageSchemaMinor   = 4
+	MessageSchemaVersion = "1.4"
+)
+
+// Kafka message types
+const (
+	MessageTypeCurrent  = "current"
+	MessageTypeForecast = "forecast"
+	MessageTypeHourly   = "hourly"
+	MessageTypeDaily    = "daily"
+)
+
+// WeatherMessage represents the message structure exchanged over Kafka
+type WeatherMessage struct {
+	SchemaVersion string                       `json:"schema_version"`       // "major.minor"; missing on messages from before versioning
+	MessageID     string                       `json:"message_id,omitempty"` // Stable ID of the observation, see NewMessageID; added in 1.1
+	Timestamp     time.Time                    `json:"timestamp"`
+	ZipCode       string                       `json:"zip_code"`
+	City          string                       `json:"city"`
+	Country       string                       `json:"country"`
+	Units         string                       `json:"units"` // "metric" (°C, m/s) or "imperial" (°F, mph); empty means metric
+	Current       *OpenWeatherResponse         `json:"current,omitempty"`
+	Forecast      *OpenWeatherForecastResponse `json:"forecast,omitempty"`
+	Hourly        *ForecastItem                `json:"hourly,omitempty"`
+	Daily         *DailyWeatherData            `json:"daily,omitempty"`
+	MessageType   string                       `json:"message_type"`         // "current", "forecast", "hourly", "daily"
+	Timezone      string                       `json:"timezone,omitempty"`   // Location's zone, e.g. "UTC-07:00", see TimezoneName; added in 1.2
+	UTCOffset     int                          `json:"utc_offset,omitempty"` // Location's offset in seconds east of UTC; added in 1.2
+}
+
+// DailyWeatherData represents daily weather summary
+type DailyWeatherData struct {
+	Day         int     `json:"day"`
+	Date        string  `json:"date"` // Local date at the location, YYYY-MM-DD
+	TempMin     float32 `json:"temp_min"`
+	TempMax     float32 `json:"temp_max"`
+	TempAvg     float32 `json:"temp_avg"`
+	Humidity    int     `json:"humidity"`
+	WindSpeed   float32 `json:"wind_speed"`
+	Description string  `json:"description"` // Condition that lasts longest during the day
+	Icon        string  `json:"icon"`
+
+	// Added in 1.3
+	FeelsLikeMin float32 `json:"feels_like_min"`
+	FeelsLikeMax float32 `json:"feels_like_max"`
+	WindGust     float32 `json:"wind_gust"` // Strongest gust
+	Clouds       int     `json:"clouds"`    // Average cloud cover, %
+	Pop          float32 `json:"pop"`       // Highest probability of precipitation, 0-1
+	Rain         float32 `json:"rain"`      // Total rain volume, mm
+	Snow         float32 `json:"snow"`      // Total snow volume, mm
+}
+
+// NewMessageID returns a stable message ID derived from the location, message type and observation time, so
+// publishing the same observation twice yields the same ID. slot tells apart messages built from one observation,
+// such as the days of a forecast.
+func NewMessageID(locationID, messageType string, observedAt int64, slot string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%s|%d|%s", locationID, messageType, observedAt, slot)))
+	return hex.EncodeToString(sum[:16])
+}
+
+// TimezoneName names a fixed UTC offset in seconds east of UTC, e.g. "UTC-07:00"
+func TimezoneName(utcOffset int) string {
+	sign := '+'
+	if utcOffset < 0 {
+		sign = '-'
+		utcOffset = -utcOffset
+	}
+	return fmt.Sprintf("UTC%c%02d:%02d", sign, utcOffset/3600, utcOffset%3600/60)
+}
+
+// ParseSchemaVersion splits a "major.minor" schema version; a bare major version means minor 0
+func ParseSchemaVersion(version string) (major, minor int, err error) {
+	majorStr, minorStr, hasMinor := strings.Cut(strings.TrimSpace(version), ".")
+
+	major, err = strconv.Atoi(majorStr)
+	if err != nil || major < 0 {
+		return 0, 0, fmt.Errorf("invalid schema version '%s'", version)
+	}
+
+	if hasMinor {
+		minor, err = strconv.Atoi(minorStr)
+		if err != nil || minor < 0 {
+			return 0, 0, fmt.Errorf("invalid schema version '%s'", version)
+		}
+	}
+
+	return major, minor, nil
+}
@@ -1,5 +1,11 @@
 package models
 
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
 // OpenWeatherResponse represents the response from OpenWeatherMap Current Weather API
 type OpenWeatherResponse struct {
 	Coord struct {
@@ -20,12 +26,15 @@ type OpenWeatherResponse struct {
 	Wind       struct {
 		Speed float32 `json:"speed"`
 		Deg   int     `json:"deg"`
+		Gust  float32 `json:"gust,omitempty"`
 	} `json:"wind"`
 	Clouds struct {
 		All int `json:"all"`
 	} `json:"clouds"`
-	Dt  int64 `json:"dt"`
-	Sys struct {
+	Rain *Precipitation `json:"rain,omitempty"`
+	Snow *Precipitation `json:"snow,omitempty"`
+	Dt   int64          `json:"dt"`
+	Sys  struct {
 		Type    int    `json:"type"`
 		Id      int    `json:"id"`
 		Country string `json:"country"`
@@ -77,15 +86,24 @@ type ForecastItem struct {
 	Wind struct {
 		Speed float32 `json:"speed"`
 		Deg   int     `json:"deg"`
+		Gust  float32 `json:"gust,omitempty"`
 	} `json:"wind"`
-	Visibility int     `json:"visibility"`
-	Pop        float32 `json:"pop"`
+	Rain       *Precipitation `json:"rain,omitempty"`
+	Snow       *Precipitation `json:"snow,omitempty"`
+	Visibility int            `json:"visibility"`
+	Pop        float32        `json:"pop"`
 	Sys        struct {
 		Pod string `json:"pod"`
 	} `json:"sys"`
 	DtTxt string `json:"dt_txt"`
 }
 
+// Precipitation represents rain or snow volume in mm, whatever the units; OpenWeatherMap omits it when there is none
+type Precipitation struct {
+	OneHour    float32 `json:"1h,omitempty"`
+	ThreeHours float32 `json:"3h,omitempty"`
+}
+
 // OpenWeatherCondition represents weather condition details
 type OpenWeatherCondition struct {
 	Id          int    `json:"id"`
@@ -103,13 +121,53 @@ type GeoResponse struct {
 	Country string  `json:"country"`
 }
 
+// Location polling priorities; low-priority locations are deferred when the API quota runs low
+const (
+	PriorityHigh   = "high"
+	PriorityNormal = "normal"
+	PriorityLow    = "low"
+)
+
 // WeatherRequest represents the input parameters for weather requests
 type WeatherRequest struct {
 	ZipCode       string
+	Country       string // ISO 3166-1 alpha-2 country code, e.g. "US", "CA", "GB", "DE"
 	Days          int
-	AlertTemp     float32
-	AlertWind     float32
+	AlertTemp     float32 // °C, whatever Units is
+	AlertWind     float32 // m/s, whatever Units is
 	AlertHumidity int
+	Provider      string // Weather provider name; empty uses the service default
+	Priority      string // PriorityHigh, PriorityNormal or PriorityLow; empty means normal
+
+	// Optional settings from the structured config file
+	ID     string               // Stable location identifier; defaults to the ZIP code, city name or coordinates
+	Name   string               // Display name
+	Units  string               // "metric" or "imperial" for provider data and messages; empty means metric
+	Tags   []string             // Free-form labels for grouping locations
+	Notify []NotificationTarget // Where alerts for this location are delivered
+
+	// Locations without a postal code; ZipCode is empty when either is set
+	City           string // "City[,State][,Country]" name resolved by direct geocoding
+	Lat            float64
+	Lon            float64
+	HasCoordinates bool
+
+	// Polling intervals; zero values fall back to the scheduler defaults
+	CurrentInterval  time.Duration
+	ForecastInterval time.Duration
+}
+
+// Notification target types
+const (
+	NotifyWebhook = "webhook"
+	NotifyEmail   = "email"
+	NotifySlack   = "slack"
+)
+
+// NotificationTarget is a destination for alerts raised for a location
+type NotificationTarget struct {
+	Type   string // NotifyWebhook, NotifyEmail or NotifySlack
+	Target string // Webhook URL, email address or Slack channel
 }
 
 // DailyForecast represents a daily weather summary
@@ -123,3 +181,38 @@ type DailyForecast struct {
 	Icon        string
 	HasAlert    bool
 }
+
+// LocationKey returns a key that uniquely identifies the requested location
+func (r WeatherRequest) LocationKey() string {
+	switch {
+	case r.ID != "":
+		return "id:" + r.ID
+	case r.HasCoordinates:
+		return "coord:" + r.LocationID()
+	case r.City != "":
+		return "city:" + strings.ToLower(r.City)
+	default:
+		return r.Country + ":" + r.ZipCode
+	}
+}
+
+// DefaultCountry is the country of ZIP codes given without one
+const DefaultCountry = "US"
+
+// LocationID returns the identifier used for the location in messages, logs and alert rules:
+// the configured ID, else the ZIP code, the city name query or "lat,lon" rounded to 4 decimal places.
+// ZIP codes outside DefaultCountry are prefixed with the country, e.g. "DE:10115", so they never clash with a US ZIP code.
+func (r WeatherRequest) LocationID() string {
+	switch {
+	case r.ID != "":
+		return r.ID
+	case r.HasCoordinates:
+		return fmt.Sprintf("%.4f,%.4f", r.Lat, r.Lon)
+	case r.City != "":
+		return r.City
+	case r.Country != "" && r.Country != DefaultCountry:
+		return r.Country + ":" + r.ZipCode
+	default:
+		return r.ZipCode
+	}
+}